@@ -1,25 +1,49 @@
 package api
 
 const (
+	HEADER_A_IM                 = "A-IM"
 	HEADER_ACCEPT               = "Accept"
+	HEADER_ACTIVITY_ID          = "x-ms-activity-id"
+	HEADER_AUTHORIZATION        = "Authorization"
+	HEADER_BATCH_ATOMIC         = "x-ms-cosmos-batch-atomic"
+	HEADER_BATCH_CONTINUE       = "x-ms-cosmos-batch-continue-on-error"
 	HEADER_CONSISTENCY_LEVEL    = "x-ms-consistency-level"
+	HEADER_CONTENT_LENGTH       = "Content-Length"
 	HEADER_CONTENT_TYPE         = "Content-Type"
 	HEADER_CONTINUATION         = "x-ms-continuation"
 	HEADER_DATE                 = "x-ms-date"
+	HEADER_ETAG                 = "etag"
+	HEADER_EXPIRY_SECONDS       = "x-ms-documentdb-expiry-seconds"
+	HEADER_IF_MATCH             = "If-Match"
+	HEADER_IF_MODIFIED_SINCE    = "If-Modified-Since"
+	HEADER_IF_NONE_MATCH        = "If-None-Match"
+	HEADER_INDEXING_DIRECTIVE   = "x-ms-indexing-directive"
+	HEADER_IS_BATCH_REQUEST     = "x-ms-cosmos-is-batch-request"
 	HEADER_IS_QUERY             = "x-ms-documentdb-isquery"
 	HEADER_IS_UPSERT            = "x-ms-documentdb-is-upsert"
+	HEADER_ITEM_COUNT           = "x-ms-item-count"
 	HEADER_MAX_ITEM_COUNT       = "x-ms-max-item-count"
 	HEADER_PARTITION_KEY        = "x-ms-documentdb-partitionkey"
+	HEADER_PARTITION_KEY_RANGE  = "x-ms-documentdb-partitionkeyrangeid"
+	HEADER_POST_TRIGGER_INCLUDE = "x-ms-documentdb-post-trigger-include"
+	HEADER_PRE_TRIGGER_INCLUDE  = "x-ms-documentdb-pre-trigger-include"
+	HEADER_PREFER               = "Prefer"
 	HEADER_QUERY_CROSSPARTITION = "x-ms-documentdb-query-enablecrosspartition"
 	HEADER_QUERY_METRICS        = "x-ms-documentdb-populatequerymetrics"
 	HEADER_OFFER_AUTOPILOT      = "x-ms-cosmos-offer-autopilot-settings"
 	HEADER_OFFER_THROUGHPUT     = "x-ms-offer-throughput"
 	HEADER_REQUEST_CHARGE       = "x-ms-request-charge"
+	HEADER_REQUEST_DURATION     = "x-ms-request-duration-ms"
 	HEADER_RESOURCE_QUOTA       = "x-ms-resource-quota"
 	HEADER_RESOURCE_USAGE       = "x-ms-resource-usage"
 	HEADER_RETRY_AFTER          = "retry-after-ms"
+	HEADER_RETRY_AFTER_MS       = "x-ms-retry-after-ms"
+	HEADER_SCRIPT_LOGGING       = "x-ms-documentdb-script-enable-logging"
+	HEADER_SCRIPT_LOG_RESULTS   = "x-ms-documentdb-script-log-results"
 	HEADER_SESSION_TOKEN        = "x-ms-session-token" // nolint:gosec
+	HEADER_SUBSTATUS            = "x-ms-substatus"
 	HEADER_VERSION              = "x-ms-version"
+	INCREMENTAL_FEED            = "Incremental feed"
 	PARTITION_KEY_VERSION       = 2
 	TIME_FORMAT                 = "Mon, 02 Jan 2006 15:04:05 GMT"
 )
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+)
+
+type BatchOperationType string
+
+const (
+	BatchOperationCreate  BatchOperationType = "Create"
+	BatchOperationUpsert  BatchOperationType = "Upsert"
+	BatchOperationRead    BatchOperationType = "Read"
+	BatchOperationReplace BatchOperationType = "Replace"
+	BatchOperationDelete  BatchOperationType = "Delete"
+	BatchOperationPatch   BatchOperationType = "Patch"
+)
+
+type BatchOperation struct {
+	OperationType BatchOperationType `json:"operationType"`
+	ID            string             `json:"id,omitempty"`
+	ResourceBody  interface{}        `json:"resourceBody,omitempty"`
+	IfMatch       string             `json:"ifMatch,omitempty"`
+	PartitionKey  string             `json:"partitionKey,omitempty"`
+}
+
+type BatchOperationResult struct {
+	StatusCode             int             `json:"statusCode"`
+	RequestCharge          float64         `json:"requestCharge"`
+	Etag                   string          `json:"eTag,omitempty"`
+	ResourceBody           json.RawMessage `json:"resourceBody,omitempty"`
+	RetryAfterMilliseconds int             `json:"retryAfterMilliseconds,omitempty"`
+}
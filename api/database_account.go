@@ -0,0 +1,31 @@
+package api
+
+type ConsistencyLevel string
+
+const (
+	ConsistencyLevelStrong           ConsistencyLevel = "Strong"
+	ConsistencyLevelBoundedStaleness ConsistencyLevel = "BoundedStaleness"
+	ConsistencyLevelSession          ConsistencyLevel = "Session"
+	ConsistencyLevelConsistentPrefix ConsistencyLevel = "ConsistentPrefix"
+	ConsistencyLevelEventual         ConsistencyLevel = "Eventual"
+)
+
+type ConsistencyPolicy struct {
+	DefaultConsistencyLevel ConsistencyLevel `json:"defaultConsistencyLevel"`
+	MaxStalenessPrefix      int              `json:"maxStalenessPrefix,omitempty"`
+	MaxIntervalInSeconds    int              `json:"maxIntervalInSeconds,omitempty"`
+}
+
+type DatabaseAccountLocation struct {
+	Name                    string `json:"name"`
+	DatabaseAccountEndpoint string `json:"databaseAccountEndpoint"`
+}
+
+type DatabaseAccount struct {
+	ID                           string                    `json:"id"`
+	Self                         string                    `json:"_self"`
+	WritableLocations            []DatabaseAccountLocation `json:"writableLocations"`
+	ReadableLocations            []DatabaseAccountLocation `json:"readableLocations"`
+	EnableMultipleWriteLocations bool                      `json:"enableMultipleWriteLocations"`
+	ConsistencyPolicy            ConsistencyPolicy         `json:"userConsistencyPolicy"`
+}
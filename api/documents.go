@@ -14,3 +14,10 @@ type ListDocumentsResponse struct {
 	Count     int               `json:"_count"`
 	Documents []json.RawMessage `json:"Documents"`
 }
+
+type IndexingDirective string
+
+const (
+	IndexingDirectiveInclude IndexingDirective = "Include"
+	IndexingDirectiveExclude IndexingDirective = "Exclude"
+)
@@ -0,0 +1,14 @@
+package api
+
+type PartitionKeyRange struct {
+	BaseModel
+
+	ID           string   `json:"id"`
+	MinInclusive string   `json:"minInclusive"`
+	MaxExclusive string   `json:"maxExclusive"`
+	Parents      []string `json:"parents"`
+}
+
+type ListPartitionKeyRangesResponse struct {
+	PartitionKeyRanges []PartitionKeyRange `json:"PartitionKeyRanges"`
+}
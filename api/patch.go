@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+)
+
+type PatchOperationType string
+
+const (
+	PatchOperationAdd       PatchOperationType = "add"
+	PatchOperationSet       PatchOperationType = "set"
+	PatchOperationReplace   PatchOperationType = "replace"
+	PatchOperationRemove    PatchOperationType = "remove"
+	PatchOperationIncrement PatchOperationType = "incr"
+	PatchOperationMove      PatchOperationType = "move"
+)
+
+type PatchOperation struct {
+	Op    PatchOperationType `json:"op"`
+	Path  string             `json:"path"`
+	From  string             `json:"from,omitempty"`
+	Value interface{}        `json:"value"`
+}
+
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	if o.Op == PatchOperationRemove || o.Op == PatchOperationMove {
+		return json.Marshal(struct {
+			Op   PatchOperationType `json:"op"`
+			Path string             `json:"path"`
+			From string             `json:"from,omitempty"`
+		}{o.Op, o.Path, o.From})
+	}
+
+	type patchOperation PatchOperation
+	return json.Marshal(patchOperation(o))
+}
+
+type PatchDocumentRequest struct {
+	Condition  string           `json:"condition,omitempty"`
+	Operations []PatchOperation `json:"operations"`
+}
@@ -0,0 +1,12 @@
+package api
+
+type StoredProcedure struct {
+	BaseModel
+
+	ID   string `json:"id"`
+	Body string `json:"body"`
+}
+
+type ListStoredProceduresResponse struct {
+	StoredProcedures []StoredProcedure `json:"StoredProcedures"`
+}
@@ -0,0 +1,30 @@
+package api
+
+type TriggerType string
+
+const (
+	TriggerTypePre  TriggerType = "Pre"
+	TriggerTypePost TriggerType = "Post"
+)
+
+type TriggerOperation string
+
+const (
+	TriggerOperationAll     TriggerOperation = "All"
+	TriggerOperationCreate  TriggerOperation = "Create"
+	TriggerOperationReplace TriggerOperation = "Replace"
+	TriggerOperationDelete  TriggerOperation = "Delete"
+)
+
+type Trigger struct {
+	BaseModel
+
+	ID               string           `json:"id"`
+	Body             string           `json:"body"`
+	TriggerType      TriggerType      `json:"triggerType"`
+	TriggerOperation TriggerOperation `json:"triggerOperation"`
+}
+
+type ListTriggersResponse struct {
+	Triggers []Trigger `json:"Triggers"`
+}
@@ -0,0 +1,33 @@
+package api
+
+type PermissionMode string
+
+const (
+	PermissionModeRead PermissionMode = "Read"
+	PermissionModeAll  PermissionMode = "All"
+)
+
+type User struct {
+	BaseModel
+
+	ID          string `json:"id"`
+	Permissions string `json:"_permissions,omitempty"`
+}
+
+type ListUsersResponse struct {
+	Users []User `json:"Users"`
+}
+
+type Permission struct {
+	BaseModel
+
+	ID                   string         `json:"id"`
+	PermissionMode       PermissionMode `json:"permissionMode"`
+	Resource             string         `json:"resource"`
+	ResourcePartitionKey []interface{}  `json:"resourcePartitionKey,omitempty"`
+	Token                string         `json:"_token,omitempty"`
+}
+
+type ListPermissionsResponse struct {
+	Permissions []Permission `json:"Permissions"`
+}
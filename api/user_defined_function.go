@@ -0,0 +1,12 @@
+package api
+
+type UserDefinedFunction struct {
+	BaseModel
+
+	ID   string `json:"id"`
+	Body string `json:"body"`
+}
+
+type ListUserDefinedFunctionsResponse struct {
+	UserDefinedFunctions []UserDefinedFunction `json:"UserDefinedFunctions"`
+}
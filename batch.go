@@ -0,0 +1,207 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const maxBatchOperations = 100
+
+type Batch struct {
+	collection   Collection
+	partitionKey interface{}
+	operations   []api.BatchOperation
+	err          error
+}
+
+type BatchResult struct {
+	api.BatchOperationResult
+}
+
+type BatchResponse struct {
+	Results      []BatchResult
+	ResponseInfo ResponseInfo
+}
+
+type batchResults []api.BatchOperationResult
+
+func (r *batchResults) decodeErrorBody(status int) bool {
+	return status != http.StatusTooManyRequests && status != httpRetryAfter
+}
+
+func (c Collection) Batch(partitionKey interface{}) *Batch {
+	return &Batch{
+		collection:   c,
+		partitionKey: partitionKey,
+	}
+}
+
+func (b *Batch) Create(document interface{}) *Batch {
+	return b.add(api.BatchOperation{OperationType: api.BatchOperationCreate, ResourceBody: document})
+}
+
+func (b *Batch) Upsert(document interface{}) *Batch {
+	return b.add(api.BatchOperation{OperationType: api.BatchOperationUpsert, ResourceBody: document})
+}
+
+func (b *Batch) Read(id string) *Batch {
+	return b.add(api.BatchOperation{OperationType: api.BatchOperationRead, ID: id})
+}
+
+func (b *Batch) Replace(document interface{}, opts ...RequestOption) *Batch {
+	documentID, err := DocumentID(document)
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	return b.add(api.BatchOperation{
+		OperationType: api.BatchOperationReplace,
+		ID:            documentID,
+		ResourceBody:  document,
+		IfMatch:       documentIfMatch(document, opts),
+	})
+}
+
+func (b *Batch) Delete(document interface{}, opts ...RequestOption) *Batch {
+	documentID, err := DocumentID(document)
+	if err != nil {
+		b.err = err
+		return b
+	}
+
+	return b.add(api.BatchOperation{
+		OperationType: api.BatchOperationDelete,
+		ID:            documentID,
+		IfMatch:       documentIfMatch(document, opts),
+	})
+}
+
+func (b *Batch) Patch(id string, ops ...PatchOperation) *Batch {
+	return b.ConditionalPatch(id, "", ops...)
+}
+
+func (b *Batch) ConditionalPatch(id string, condition string, ops ...PatchOperation) *Batch {
+	if len(ops) == 0 {
+		b.err = &CosmosError{Code: ErrBadRequest, Message: "no patch operations specified"}
+		return b
+	}
+
+	return b.add(api.BatchOperation{
+		OperationType: api.BatchOperationPatch,
+		ID:            id,
+		ResourceBody: api.PatchDocumentRequest{
+			Condition:  condition,
+			Operations: ops,
+		},
+	})
+}
+
+func (b *Batch) Len() int {
+	return len(b.operations)
+}
+
+func (b *Batch) add(op api.BatchOperation) *Batch {
+	b.operations = append(b.operations, op)
+	return b
+}
+
+func (b *Batch) Execute(ctx context.Context) (*BatchResponse, error) {
+	c := b.collection
+
+	span, ctx := c.startCollectionSpan(ctx, "cosmos.ExecuteBatch")
+	defer span.Finish()
+
+	span.SetTag("cosmos.batch_operations", len(b.operations))
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if len(b.operations) == 0 {
+		return nil, &CosmosError{Code: ErrBadRequest, Message: "batch contains no operations"}
+	}
+
+	if len(b.operations) > maxBatchOperations {
+		return nil, &CosmosError{Code: ErrBadRequest, Message: "batch contains more than " + strconv.Itoa(maxBatchOperations) + " operations"}
+	}
+
+	headers := map[string]string{
+		api.HEADER_PARTITION_KEY:    makePartitionKeyHeaderValue(b.partitionKey),
+		api.HEADER_IS_BATCH_REQUEST: "True",
+		api.HEADER_BATCH_ATOMIC:     "True",
+	}
+
+	b.invalidateDocuments(headers[api.HEADER_PARTITION_KEY])
+
+	var results batchResults
+	httpRes, err := c.database.Client().post(ctx, createDocumentLink(c.database.ID, c.ID, ""), b.operations, &results, headers)
+	if err != nil && len(results) == 0 {
+		return nil, err
+	}
+
+	res := &BatchResponse{
+		Results:      make([]BatchResult, len(results)),
+		ResponseInfo: newResponseInfo(httpRes),
+	}
+	for i, r := range results {
+		res.Results[i] = BatchResult{
+			BatchOperationResult: r,
+		}
+	}
+
+	if err != nil {
+		return res, batchError(res, err)
+	}
+
+	return res, nil
+}
+
+func (b *Batch) invalidateDocuments(partitionKey string) {
+	c := b.collection
+	for _, op := range b.operations {
+		if op.OperationType == api.BatchOperationRead {
+			continue
+		}
+
+		documentID := op.ID
+		if documentID == "" {
+			documentID, _ = DocumentID(op.ResourceBody)
+		}
+
+		if documentID != "" {
+			c.database.Client().invalidateDocument(documentCacheKey(createDocumentLink(c.database.ID, c.ID, documentID), partitionKey))
+		}
+	}
+}
+
+func batchError(res *BatchResponse, err error) error {
+	for i, r := range res.Results {
+		if r.StatusCode >= http.StatusBadRequest && r.StatusCode != http.StatusFailedDependency {
+			return &CosmosError{
+				Code:              errorCodeFromStatus(r.StatusCode),
+				Message:           "batch operation " + strconv.Itoa(i) + " failed with status " + strconv.Itoa(r.StatusCode),
+				OperationIndex:    i,
+				HasOperationIndex: true,
+			}
+		}
+	}
+
+	return err
+}
+
+func (r BatchResult) Succeeded() bool {
+	return r.StatusCode >= http.StatusOK && r.StatusCode < http.StatusMultipleChoices
+}
+
+func (r BatchResult) Decode(out interface{}) error {
+	if len(r.ResourceBody) == 0 {
+		return &CosmosError{Code: ErrNotFound, Message: "batch operation returned no resource body"}
+	}
+
+	return json.Unmarshal(r.ResourceBody, out)
+}
@@ -0,0 +1,112 @@
+package cosmos_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+)
+
+func TestBatch(t *testing.T) {
+	_, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "existing"}, Owner: "alice", Balance: 1}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	res, err := coll.Batch("alice").
+		Create(account{Document: cosmos.Document{ID: "order"}, Owner: "alice", Balance: 30}).
+		Create(account{Document: cosmos.Document{ID: "line-1"}, Owner: "alice", Balance: 10}).
+		Upsert(account{Document: cosmos.Document{ID: "line-2"}, Owner: "alice", Balance: 20}).
+		Patch("existing", cosmos.PatchIncrement("/balance", 4)).
+		Read("order").
+		Execute(ctx)
+	if err != nil {
+		t.Fatalf("failed to execute batch: %v", err)
+	}
+
+	if len(res.Results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(res.Results))
+	}
+
+	for i, status := range []int{http.StatusCreated, http.StatusCreated, http.StatusCreated, http.StatusOK, http.StatusOK} {
+		if res.Results[i].StatusCode != status || !res.Results[i].Succeeded() {
+			t.Errorf("expected operation %d to return %d, got %d", i, status, res.Results[i].StatusCode)
+		}
+		if res.Results[i].Etag == "" {
+			t.Errorf("expected operation %d to return an etag", i)
+		}
+	}
+
+	var order account
+	if err := res.Results[4].Decode(&order); err != nil {
+		t.Fatalf("failed to decode read result: %v", err)
+	}
+	if order.Balance != 30 {
+		t.Errorf("expected read to observe the order created in the same batch, got %+v", order)
+	}
+
+	var patched account
+	if err := res.Results[3].Decode(&patched); err != nil || patched.Balance != 5 {
+		t.Errorf("expected patched balance 5, got %+v (%v)", patched, err)
+	}
+
+	res, err = coll.Batch("alice").
+		Delete(order).
+		Create(account{Document: cosmos.Document{ID: "line-1"}, Owner: "alice"}).
+		Execute(ctx)
+	if !cosmos.IsConflict(err) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if cerr := err.(*cosmos.CosmosError); !cerr.HasOperationIndex || cerr.OperationIndex != 1 {
+		t.Errorf("expected failing operation index 1, got %+v", cerr)
+	}
+	if res == nil || res.Results[0].StatusCode != http.StatusFailedDependency {
+		t.Errorf("expected the other operations to fail with a dependency error, got %+v", res)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "order", &order); err != nil {
+		t.Errorf("expected failed batch to be rolled back: %v", err)
+	}
+
+	if _, err := coll.Batch("alice").Execute(ctx); !cosmos.IsBadRequest(err) || err.(*cosmos.CosmosError).HasOperationIndex {
+		t.Errorf("expected empty batch to be rejected without an operation index, got %+v", err)
+	}
+}
+
+func TestBatchIfMatchDocumentEtag(t *testing.T) {
+	_, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var stale account
+	if err := coll.GetDocument(ctx, "alice", "1", &stale); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+
+	current := stale
+	current.Balance = 10
+	if err := coll.ReplaceDocument(ctx, "alice", current); err != nil {
+		t.Fatalf("failed to replace document: %v", err)
+	}
+
+	stale.Balance = 20
+	if _, err := coll.Batch("alice").Replace(stale, cosmos.WithIfMatchDocumentEtag()).Execute(ctx); !cosmos.IsConcurrency(err) {
+		t.Errorf("expected concurrency error for stale replace, got %v", err)
+	}
+	if _, err := coll.Batch("alice").Delete(stale, cosmos.WithIfMatchDocumentEtag()).Execute(ctx); !cosmos.IsConcurrency(err) {
+		t.Errorf("expected concurrency error for stale delete, got %v", err)
+	}
+
+	if _, err := coll.Batch("alice").Replace(stale).Execute(ctx); err != nil {
+		t.Errorf("expected replace without If-Match to ignore the stale etag, got %v", err)
+	}
+	if _, err := coll.Batch("alice").Delete(stale).Execute(ctx); err != nil {
+		t.Errorf("expected delete without If-Match to ignore the stale etag, got %v", err)
+	}
+}
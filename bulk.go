@@ -0,0 +1,598 @@
+package cosmos
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type bulkOptions struct {
+	maxConcurrency int
+	maxBatchSize   int
+	flushInterval  time.Duration
+	maxRetries     int
+}
+
+type BulkOption func(*bulkOptions)
+
+func BulkMaxConcurrency(concurrency int) BulkOption {
+	return func(o *bulkOptions) {
+		o.maxConcurrency = concurrency
+	}
+}
+
+func BulkMaxBatchSize(size int) BulkOption {
+	return func(o *bulkOptions) {
+		o.maxBatchSize = size
+	}
+}
+
+func BulkFlushInterval(interval time.Duration) BulkOption {
+	return func(o *bulkOptions) {
+		o.flushInterval = interval
+	}
+}
+
+func BulkMaxRetries(retries int) BulkOption {
+	return func(o *bulkOptions) {
+		o.maxRetries = retries
+	}
+}
+
+type BulkOperation struct {
+	partitionKey interface{}
+	operation    api.BatchOperation
+	err          error
+}
+
+func BulkCreate(partitionKey interface{}, document interface{}) BulkOperation {
+	return BulkOperation{partitionKey: partitionKey, operation: api.BatchOperation{OperationType: api.BatchOperationCreate, ResourceBody: document}}
+}
+
+func BulkUpsert(partitionKey interface{}, document interface{}) BulkOperation {
+	return BulkOperation{partitionKey: partitionKey, operation: api.BatchOperation{OperationType: api.BatchOperationUpsert, ResourceBody: document}}
+}
+
+func BulkRead(partitionKey interface{}, id string) BulkOperation {
+	return BulkOperation{partitionKey: partitionKey, operation: api.BatchOperation{OperationType: api.BatchOperationRead, ID: id}}
+}
+
+func BulkReplace(partitionKey interface{}, document interface{}, opts ...RequestOption) BulkOperation {
+	documentID, err := DocumentID(document)
+	return BulkOperation{
+		partitionKey: partitionKey,
+		operation: api.BatchOperation{
+			OperationType: api.BatchOperationReplace,
+			ID:            documentID,
+			ResourceBody:  document,
+			IfMatch:       documentIfMatch(document, opts),
+		},
+		err: err,
+	}
+}
+
+func BulkDelete(partitionKey interface{}, document interface{}, opts ...RequestOption) BulkOperation {
+	documentID, err := DocumentID(document)
+	return BulkOperation{
+		partitionKey: partitionKey,
+		operation: api.BatchOperation{
+			OperationType: api.BatchOperationDelete,
+			ID:            documentID,
+			IfMatch:       documentIfMatch(document, opts),
+		},
+		err: err,
+	}
+}
+
+func BulkPatch(partitionKey interface{}, id string, ops ...PatchOperation) BulkOperation {
+	op := BulkOperation{
+		partitionKey: partitionKey,
+		operation: api.BatchOperation{
+			OperationType: api.BatchOperationPatch,
+			ID:            id,
+			ResourceBody:  api.PatchDocumentRequest{Operations: ops},
+		},
+	}
+
+	if len(ops) == 0 {
+		op.err = &CosmosError{Code: ErrBadRequest, Message: "no patch operations specified"}
+	}
+
+	return op
+}
+
+type BulkResult struct {
+	BatchResult
+
+	Index        int
+	PartitionKey interface{}
+	Operation    api.BatchOperationType
+	Err          error
+}
+
+type BulkStats struct {
+	Operations    int
+	Succeeded     int
+	Failed        int
+	Throttled     int
+	RequestCharge float64
+}
+
+type BulkExecutor struct {
+	ctx        context.Context
+	collection Collection
+	options    bulkOptions
+	results    chan BulkResult
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	ready    *sync.Cond
+	pending  map[string][]*bulkItem
+	queued   []BulkResult
+	next     int
+	closed   bool
+	finished bool
+	limit    int
+	inflight int
+	stats    BulkStats
+
+	rangesMu sync.Mutex
+	ranges   []PartitionKeyRange
+
+	operations sync.WaitGroup
+	batches    sync.WaitGroup
+	done       chan struct{}
+}
+
+type bulkItem struct {
+	index                 int
+	partitionKey          interface{}
+	header                string
+	effectivePartitionKey string
+	rangeID               string
+	operation             api.BatchOperation
+	attempts              int
+}
+
+func (c Collection) Bulk(ctx context.Context, opts ...BulkOption) *BulkExecutor {
+	options := bulkOptions{
+		maxConcurrency: 8,
+		maxBatchSize:   maxBatchOperations,
+		flushInterval:  100 * time.Millisecond,
+		maxRetries:     10,
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.maxConcurrency < 1 {
+		options.maxConcurrency = 1
+	}
+	if options.maxBatchSize < 1 || options.maxBatchSize > maxBatchOperations {
+		options.maxBatchSize = maxBatchOperations
+	}
+
+	e := &BulkExecutor{
+		ctx:        ctx,
+		collection: c,
+		options:    options,
+		results:    make(chan BulkResult, options.maxBatchSize*options.maxConcurrency),
+		pending:    make(map[string][]*bulkItem),
+		limit:      options.maxConcurrency,
+		done:       make(chan struct{}),
+	}
+	e.cond = sync.NewCond(&e.mu)
+	e.ready = sync.NewCond(&e.mu)
+
+	go e.flushLoop()
+	go e.forward()
+
+	return e
+}
+
+// Results delivers one result per added operation and is closed once Close has
+// returned and every result has been received. Callers may read it before or
+// after calling Close, but must drain it until it is closed.
+func (e *BulkExecutor) Results() <-chan BulkResult {
+	return e.results
+}
+
+func (e *BulkExecutor) Add(op BulkOperation) (int, error) {
+	if op.err != nil {
+		return -1, op.err
+	}
+
+	if err := e.ctx.Err(); err != nil {
+		return -1, err
+	}
+
+	effectivePartitionKey := EffectivePartitionKey(op.partitionKey)
+	rangeID, err := e.partitionKeyRange(effectivePartitionKey)
+	if err != nil {
+		return -1, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return -1, &CosmosError{Code: ErrBadRequest, Message: "bulk executor is closed"}
+	}
+
+	item := &bulkItem{
+		index:                 e.next,
+		partitionKey:          op.partitionKey,
+		header:                makePartitionKeyHeaderValue(op.partitionKey),
+		effectivePartitionKey: effectivePartitionKey,
+		rangeID:               rangeID,
+		operation:             op.operation,
+	}
+	e.next++
+	e.stats.Operations++
+	e.operations.Add(1)
+
+	e.enqueue(item)
+	return item.index, nil
+}
+
+func (e *BulkExecutor) Close() BulkStats {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return e.Stats()
+	}
+	e.closed = true
+	e.flush()
+	e.mu.Unlock()
+
+	e.operations.Wait()
+	close(e.done)
+	e.batches.Wait()
+
+	e.mu.Lock()
+	e.finished = true
+	e.ready.Broadcast()
+	e.mu.Unlock()
+
+	return e.Stats()
+}
+
+func (e *BulkExecutor) Stats() BulkStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.stats
+}
+
+func (e *BulkExecutor) Concurrency() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.limit
+}
+
+func (e *BulkExecutor) partitionKeyRange(effectivePartitionKey string) (string, error) {
+	e.rangesMu.Lock()
+	defer e.rangesMu.Unlock()
+
+	if e.ranges == nil {
+		ranges, err := e.collection.ListPartitionKeyRanges(e.ctx)
+		if err != nil {
+			return "", err
+		}
+		e.ranges = ranges
+	}
+
+	for _, r := range e.ranges {
+		if effectivePartitionKey >= r.MinInclusive && effectivePartitionKey < r.MaxExclusive {
+			return r.ID, nil
+		}
+	}
+
+	return "", &CosmosError{Code: ErrInternalServerError, Message: "no partition key range contains effective partition key " + effectivePartitionKey}
+}
+
+func (e *BulkExecutor) refreshPartitionKeyRanges(goneRangeID string) error {
+	e.rangesMu.Lock()
+	defer e.rangesMu.Unlock()
+
+	for _, r := range e.ranges {
+		if r.ID != goneRangeID {
+			continue
+		}
+
+		ranges, err := e.collection.ListPartitionKeyRanges(e.ctx)
+		if err != nil {
+			return err
+		}
+		e.ranges = ranges
+		break
+	}
+
+	return nil
+}
+
+func (e *BulkExecutor) enqueue(item *bulkItem) {
+	items := append(e.pending[item.rangeID], item)
+	if len(items) < e.options.maxBatchSize && !e.closed {
+		e.pending[item.rangeID] = items
+		return
+	}
+
+	delete(e.pending, item.rangeID)
+	e.dispatch(items)
+}
+
+func (e *BulkExecutor) flush() {
+	for rangeID, items := range e.pending {
+		delete(e.pending, rangeID)
+		e.dispatch(items)
+	}
+}
+
+func (e *BulkExecutor) flushLoop() {
+	ticker := time.NewTicker(e.options.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+			e.mu.Lock()
+			e.flush()
+			e.mu.Unlock()
+		}
+	}
+}
+
+func (e *BulkExecutor) forward() {
+	defer close(e.results)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for {
+		for len(e.queued) == 0 && !e.finished {
+			e.ready.Wait()
+		}
+
+		if len(e.queued) == 0 {
+			return
+		}
+
+		r := e.queued[0]
+		e.queued = e.queued[1:]
+
+		e.mu.Unlock()
+		e.results <- r
+		e.mu.Lock()
+	}
+}
+
+func (e *BulkExecutor) dispatch(items []*bulkItem) {
+	for e.inflight >= e.limit {
+		e.cond.Wait()
+	}
+
+	e.inflight++
+	e.batches.Add(1)
+	go e.execute(items)
+}
+
+func (e *BulkExecutor) execute(items []*bulkItem) {
+	defer e.batches.Done()
+
+	c := e.collection
+	span, ctx := c.startCollectionSpan(e.ctx, "cosmos.BulkBatch")
+	defer span.Finish()
+
+	span.SetTag("cosmos.batch_operations", len(items))
+
+	ops := make([]api.BatchOperation, len(items))
+	for i, item := range items {
+		ops[i] = item.operation
+		ops[i].PartitionKey = item.header
+	}
+
+	headers := map[string]string{
+		api.HEADER_PARTITION_KEY_RANGE: items[0].rangeID,
+		api.HEADER_IS_BATCH_REQUEST:    "True",
+		api.HEADER_BATCH_ATOMIC:        "False",
+		api.HEADER_BATCH_CONTINUE:      "True",
+	}
+
+	for _, item := range items {
+		e.invalidateDocument(item)
+	}
+
+	var results batchResults
+	res, err := c.database.Client().post(withoutRetries(ctx), createDocumentLink(c.database.ID, c.ID, ""), ops, &results, headers)
+
+	var completed []BulkResult
+	var retry []*bulkItem
+	var rerouted []*bulkItem
+	throttled := false
+	retryAfter := time.Duration(0)
+	charge := 0.0
+
+	switch {
+	case IsTooManyRequests(err):
+		throttled = true
+		retry = items
+		if res != nil {
+			retryAfter, _ = retryAfterHeader(res.Header)
+		}
+
+	case IsGone(err):
+		rerouted, completed = e.reroute(items, err)
+
+	case err != nil && len(results) != len(items):
+		for _, item := range items {
+			completed = append(completed, e.result(item, api.BatchOperationResult{}, err))
+		}
+
+	default:
+		for i, item := range items {
+			if i >= len(results) {
+				completed = append(completed, e.result(item, api.BatchOperationResult{}, &CosmosError{Code: ErrInternalServerError, Message: "missing bulk operation result"}))
+				continue
+			}
+
+			r := results[i]
+			charge += r.RequestCharge
+
+			if r.StatusCode == http.StatusTooManyRequests {
+				throttled = true
+				retry = append(retry, item)
+				if d := time.Duration(r.RetryAfterMilliseconds) * time.Millisecond; d > retryAfter {
+					retryAfter = d
+				}
+				continue
+			}
+
+			var opErr error
+			if r.StatusCode >= http.StatusBadRequest {
+				opErr = &CosmosError{
+					Code:              errorCodeFromStatus(r.StatusCode),
+					Message:           "bulk operation " + strconv.Itoa(item.index) + " failed with status " + strconv.Itoa(r.StatusCode),
+					OperationIndex:    item.index,
+					HasOperationIndex: true,
+				}
+			}
+			completed = append(completed, e.result(item, r, opErr))
+		}
+	}
+
+	var exhausted []*bulkItem
+	if throttled {
+		var remaining []*bulkItem
+		for _, item := range retry {
+			item.attempts++
+			if item.attempts > e.options.maxRetries {
+				exhausted = append(exhausted, item)
+				continue
+			}
+			remaining = append(remaining, item)
+		}
+		retry = remaining
+	}
+
+	for _, item := range exhausted {
+		completed = append(completed, e.result(item, api.BatchOperationResult{StatusCode: http.StatusTooManyRequests}, &CosmosError{
+			Code:              ErrTooManyRequests,
+			Message:           "bulk operation " + strconv.Itoa(item.index) + " was throttled too many times",
+			OperationIndex:    item.index,
+			HasOperationIndex: true,
+		}))
+	}
+
+	e.mu.Lock()
+	e.inflight--
+	if throttled {
+		e.stats.Throttled++
+		if e.limit = e.limit / 2; e.limit < 1 {
+			e.limit = 1
+		}
+	} else if e.limit < e.options.maxConcurrency {
+		e.limit++
+	}
+	e.stats.RequestCharge += charge
+	for _, r := range completed {
+		if r.Err != nil {
+			e.stats.Failed++
+		} else {
+			e.stats.Succeeded++
+		}
+	}
+	e.queued = append(e.queued, completed...)
+	e.cond.Broadcast()
+	e.ready.Broadcast()
+	e.mu.Unlock()
+
+	for range completed {
+		e.operations.Done()
+	}
+
+	if len(rerouted) > 0 {
+		e.mu.Lock()
+		for _, item := range rerouted {
+			e.enqueue(item)
+		}
+		e.mu.Unlock()
+	}
+
+	if len(retry) > 0 {
+		if retryAfter <= 0 {
+			retryAfter = defaultRetryAfter
+		}
+
+		time.AfterFunc(retryAfter, func() {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+
+			for _, item := range retry {
+				e.enqueue(item)
+			}
+		})
+	}
+}
+
+func (e *BulkExecutor) reroute(items []*bulkItem, goneErr error) ([]*bulkItem, []BulkResult) {
+	var rerouted []*bulkItem
+	var completed []BulkResult
+
+	refreshErr := e.refreshPartitionKeyRanges(items[0].rangeID)
+	for _, item := range items {
+		item.attempts++
+
+		err := refreshErr
+		if err == nil && item.attempts > e.options.maxRetries {
+			err = goneErr
+		}
+
+		if err == nil {
+			item.rangeID, err = e.partitionKeyRange(item.effectivePartitionKey)
+		}
+
+		if err != nil {
+			completed = append(completed, e.result(item, api.BatchOperationResult{StatusCode: http.StatusGone}, err))
+			continue
+		}
+
+		rerouted = append(rerouted, item)
+	}
+
+	return rerouted, completed
+}
+
+func (e *BulkExecutor) result(item *bulkItem, r api.BatchOperationResult, err error) BulkResult {
+	return BulkResult{
+		BatchResult:  BatchResult{BatchOperationResult: r},
+		Index:        item.index,
+		PartitionKey: item.partitionKey,
+		Operation:    item.operation.OperationType,
+		Err:          err,
+	}
+}
+
+func (e *BulkExecutor) invalidateDocument(item *bulkItem) {
+	if item.operation.OperationType == api.BatchOperationRead {
+		return
+	}
+
+	documentID := item.operation.ID
+	if documentID == "" {
+		documentID, _ = DocumentID(item.operation.ResourceBody)
+	}
+
+	if documentID != "" {
+		c := e.collection
+		c.database.Client().invalidateDocument(documentCacheKey(createDocumentLink(c.database.ID, c.ID, documentID), item.header))
+	}
+}
@@ -0,0 +1,259 @@
+package cosmos_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func TestBulk(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "owner0", account{Document: cosmos.Document{ID: "0"}, Owner: "owner0"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	bulk := coll.Bulk(ctx, cosmos.BulkMaxConcurrency(4), cosmos.BulkMaxBatchSize(20), cosmos.BulkFlushInterval(5*time.Millisecond))
+
+	results := make(map[int]cosmos.BulkResult)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for r := range bulk.Results() {
+			results[r.Index] = r
+		}
+	}()
+
+	for i := 0; i < 250; i++ {
+		owner := "owner" + strconv.Itoa(i%5)
+		if _, err := bulk.Add(cosmos.BulkCreate(owner, account{Document: cosmos.Document{ID: strconv.Itoa(i)}, Owner: owner, Balance: i})); err != nil {
+			t.Fatalf("failed to add operation: %v", err)
+		}
+
+		if i == 0 {
+			server.Throttle(3)
+		}
+	}
+
+	stats := bulk.Close()
+	<-done
+
+	if _, err := bulk.Add(cosmos.BulkRead("owner0", "0")); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected adding to a closed executor to fail, got %v", err)
+	}
+
+	if len(results) != 250 {
+		t.Fatalf("expected 250 results, got %d", len(results))
+	}
+
+	if stats.Operations != 250 || stats.Succeeded != 249 || stats.Failed != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.Throttled == 0 {
+		t.Errorf("expected throttled batches to be recorded, got %+v", stats)
+	}
+	if stats.RequestCharge != 250 {
+		t.Errorf("expected aggregate request charge 250, got %v", stats.RequestCharge)
+	}
+
+	if r := results[0]; !cosmos.IsConflict(r.Err) || !r.Err.(*cosmos.CosmosError).HasOperationIndex || r.Err.(*cosmos.CosmosError).OperationIndex != 0 {
+		t.Errorf("expected conflict for operation 0, got %+v", r)
+	}
+
+	var created account
+	if err := results[42].Decode(&created); err != nil || created.Balance != 42 {
+		t.Errorf("expected result 42 to contain the created document, got %+v (%v)", created, err)
+	}
+
+	if err := coll.GetDocument(ctx, "owner4", "249", &created); err != nil {
+		t.Errorf("expected document 249 to exist: %v", err)
+	}
+}
+
+func TestBulkGroupsByPartitionKeyRange(t *testing.T) {
+	var mu sync.Mutex
+	batches := make(map[string]int)
+
+	record := func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			if req.Header.Get(api.HEADER_IS_BATCH_REQUEST) != "" {
+				mu.Lock()
+				batches[req.Header.Get(api.HEADER_PARTITION_KEY_RANGE)]++
+				mu.Unlock()
+			}
+			return next(ctx, req)
+		}
+	}
+
+	server := cosmostest.NewServer(cosmostest.WithPartitionKeyRanges(4))
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(cosmos.WithMiddleware(record))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, _ := client.CreateDatabase(ctx, "bank")
+	coll, err := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	ranges, err := coll.ListPartitionKeyRanges(ctx)
+	if err != nil {
+		t.Fatalf("failed to list partition key ranges: %v", err)
+	}
+
+	bulk := coll.Bulk(ctx, cosmos.BulkFlushInterval(time.Hour))
+	for i := 0; i < 40; i++ {
+		owner := "owner" + strconv.Itoa(i)
+		if _, err := bulk.Add(cosmos.BulkCreate(owner, account{Document: cosmos.Document{ID: "1"}, Owner: owner})); err != nil {
+			t.Fatalf("failed to add operation: %v", err)
+		}
+	}
+
+	if err := server.SplitPartitionKeyRange("bank", "accounts", ranges[0].ID); err != nil {
+		t.Fatalf("failed to split partition key range: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for r := range bulk.Results() {
+			if r.Err != nil {
+				t.Errorf("unexpected error for operation %d: %v", r.Index, r.Err)
+			}
+		}
+	}()
+
+	stats := bulk.Close()
+	<-done
+
+	if stats.Succeeded != 40 || stats.Failed != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, r := range ranges {
+		if batches[r.ID] != 1 {
+			t.Errorf("expected a single batch for partition key range %s, got %d", r.ID, batches[r.ID])
+		}
+	}
+
+	if len(batches) <= len(ranges) {
+		t.Errorf("expected operations for the split range to be rerouted to its children, got %v", batches)
+	}
+
+	for i := 0; i < 40; i++ {
+		owner := "owner" + strconv.Itoa(i)
+		var created account
+		if err := coll.GetDocument(ctx, owner, "1", &created); err != nil {
+			t.Errorf("expected document for %s to exist: %v", owner, err)
+		}
+	}
+}
+
+func TestBulkReadResultsAfterClose(t *testing.T) {
+	_, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	bulk := coll.Bulk(ctx, cosmos.BulkMaxConcurrency(1), cosmos.BulkMaxBatchSize(2))
+	for i := 0; i < 50; i++ {
+		owner := "owner" + strconv.Itoa(i%3)
+		if _, err := bulk.Add(cosmos.BulkCreate(owner, account{Document: cosmos.Document{ID: strconv.Itoa(i)}, Owner: owner})); err != nil {
+			t.Fatalf("failed to add operation: %v", err)
+		}
+	}
+
+	closed := make(chan cosmos.BulkStats)
+	go func() {
+		closed <- bulk.Close()
+	}()
+
+	var stats cosmos.BulkStats
+	select {
+	case stats = <-closed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected Close to return without the results being consumed")
+	}
+
+	if stats.Succeeded != 50 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	count := 0
+	for r := range bulk.Results() {
+		if r.Err != nil {
+			t.Errorf("unexpected error for operation %d: %v", r.Index, r.Err)
+		}
+		count++
+	}
+
+	if count != 50 {
+		t.Errorf("expected 50 results, got %d", count)
+	}
+}
+
+func TestBulkIfMatchDocumentEtag(t *testing.T) {
+	_, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	var stale []account
+	for i := 0; i < 4; i++ {
+		acc := account{Document: cosmos.Document{ID: strconv.Itoa(i)}, Owner: "alice"}
+		if err := coll.CreateDocument(ctx, "alice", acc, false); err != nil {
+			t.Fatalf("failed to create document: %v", err)
+		}
+		if err := coll.GetDocument(ctx, "alice", acc.ID, &acc); err != nil {
+			t.Fatalf("failed to get document: %v", err)
+		}
+
+		current := acc
+		current.Balance = 10
+		if err := coll.ReplaceDocument(ctx, "alice", current); err != nil {
+			t.Fatalf("failed to replace document: %v", err)
+		}
+
+		stale = append(stale, acc)
+	}
+
+	bulk := coll.Bulk(ctx, cosmos.BulkMaxBatchSize(1))
+	ops := []cosmos.BulkOperation{
+		cosmos.BulkReplace("alice", stale[0], cosmos.WithIfMatchDocumentEtag()),
+		cosmos.BulkDelete("alice", stale[1], cosmos.WithIfMatchDocumentEtag()),
+		cosmos.BulkReplace("alice", stale[2]),
+		cosmos.BulkDelete("alice", stale[3]),
+	}
+	for _, op := range ops {
+		if _, err := bulk.Add(op); err != nil {
+			t.Fatalf("failed to add operation: %v", err)
+		}
+	}
+	bulk.Close()
+
+	results := make(map[int]cosmos.BulkResult)
+	for r := range bulk.Results() {
+		results[r.Index] = r
+	}
+
+	for i := 0; i < 2; i++ {
+		if !cosmos.IsConcurrency(results[i].Err) {
+			t.Errorf("expected concurrency error for stale operation %d, got %v", i, results[i].Err)
+		}
+	}
+	for i := 2; i < 4; i++ {
+		if results[i].Err != nil {
+			t.Errorf("expected operation %d without If-Match to ignore the stale etag, got %v", i, results[i].Err)
+		}
+	}
+}
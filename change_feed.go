@@ -0,0 +1,278 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type ChangeFeedState struct {
+	Ranges map[string]string `json:"ranges"`
+}
+
+func ParseChangeFeedContinuation(continuation string) (ChangeFeedState, error) {
+	var state ChangeFeedState
+
+	b, err := base64.StdEncoding.DecodeString(continuation)
+	if err != nil {
+		return state, &CosmosError{Code: ErrBadRequest, Message: "invalid change feed continuation: " + err.Error()}
+	}
+
+	if err := json.Unmarshal(b, &state); err != nil {
+		return state, &CosmosError{Code: ErrBadRequest, Message: "invalid change feed continuation: " + err.Error()}
+	}
+
+	return state, nil
+}
+
+func (s ChangeFeedState) Continuation() string {
+	b, _ := json.Marshal(s)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+type changeFeedOptions struct {
+	startFromBeginning bool
+	startTime          time.Time
+	state              *ChangeFeedState
+	maxItemCount       int
+	err                error
+}
+
+type ChangeFeedOption func(*changeFeedOptions)
+
+func ChangeFeedFromBeginning() ChangeFeedOption {
+	return func(o *changeFeedOptions) {
+		o.startFromBeginning = true
+	}
+}
+
+func ChangeFeedFromTime(startTime time.Time) ChangeFeedOption {
+	return func(o *changeFeedOptions) {
+		o.startTime = startTime
+	}
+}
+
+func ChangeFeedFromState(state ChangeFeedState) ChangeFeedOption {
+	return func(o *changeFeedOptions) {
+		o.state = &state
+	}
+}
+
+func ChangeFeedFromContinuation(continuation string) ChangeFeedOption {
+	return func(o *changeFeedOptions) {
+		state, err := ParseChangeFeedContinuation(continuation)
+		if err != nil {
+			o.err = err
+			return
+		}
+
+		o.state = &state
+	}
+}
+
+func ChangeFeedMaxItemCount(maxItemCount int) ChangeFeedOption {
+	return func(o *changeFeedOptions) {
+		o.maxItemCount = maxItemCount
+	}
+}
+
+type ChangeFeedIterator struct {
+	ctx        context.Context
+	collection Collection
+	options    changeFeedOptions
+	ranges     []string
+	state      ChangeFeedState
+	current    int
+	pending    string
+	documents  []json.RawMessage
+	position   int
+	err        error
+}
+
+func (c Collection) ListPartitionKeyRanges(ctx context.Context) ([]PartitionKeyRange, error) {
+	span, ctx := c.startCollectionSpan(ctx, "cosmos.ListPartitionKeyRanges")
+	defer span.Finish()
+
+	var res api.ListPartitionKeyRangesResponse
+	if _, err := c.database.Client().get(ctx, createPartitionKeyRangeLink(c.database.ID, c.ID), &res, nil); err != nil {
+		return nil, err
+	}
+
+	return res.PartitionKeyRanges, nil
+}
+
+func (c Collection) ReadChangeFeed(ctx context.Context, opts ...ChangeFeedOption) (*ChangeFeedIterator, error) {
+	span, spanCtx := c.startCollectionSpan(ctx, "cosmos.ReadChangeFeed")
+	defer span.Finish()
+
+	var options changeFeedOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.err != nil {
+		return nil, options.err
+	}
+
+	ranges, err := c.ListPartitionKeyRanges(spanCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &ChangeFeedIterator{
+		ctx:        ctx,
+		collection: c,
+		options:    options,
+		state:      ChangeFeedState{Ranges: make(map[string]string, len(ranges))},
+	}
+
+	for _, r := range ranges {
+		it.ranges = append(it.ranges, r.ID)
+		it.state.Ranges[r.ID] = initialChangeFeedEtag(r, options)
+	}
+
+	return it, nil
+}
+
+func initialChangeFeedEtag(r PartitionKeyRange, options changeFeedOptions) string {
+	if options.state != nil {
+		if etag, ok := options.state.Ranges[r.ID]; ok {
+			return etag
+		}
+
+		for i := len(r.Parents) - 1; i >= 0; i-- {
+			if etag, ok := options.state.Ranges[r.Parents[i]]; ok {
+				return etag
+			}
+		}
+	}
+
+	if options.startFromBeginning || !options.startTime.IsZero() {
+		return ""
+	}
+
+	return "*"
+}
+
+func (it *ChangeFeedIterator) Next(out interface{}) bool {
+	for it.err == nil {
+		if it.position < len(it.documents) {
+			it.err = json.Unmarshal(it.documents[it.position], out)
+			it.position++
+
+			if it.position == len(it.documents) {
+				it.state.Ranges[it.ranges[it.current]] = it.pending
+			}
+			return true
+		}
+
+		if it.current >= len(it.ranges) {
+			return false
+		}
+
+		more, err := it.fetchNext()
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		if !more {
+			it.current++
+		}
+	}
+
+	return false
+}
+
+func (it *ChangeFeedIterator) State() ChangeFeedState {
+	state := ChangeFeedState{Ranges: make(map[string]string, len(it.state.Ranges))}
+	for k, v := range it.state.Ranges {
+		state.Ranges[k] = v
+	}
+	return state
+}
+
+func (it *ChangeFeedIterator) Continuation() string {
+	return it.State().Continuation()
+}
+
+func (it *ChangeFeedIterator) Err() error {
+	return it.err
+}
+
+func (it *ChangeFeedIterator) fetchNext() (bool, error) {
+	rangeID := it.ranges[it.current]
+	result, res, err := readChangeFeedPage(it.ctx, it.collection, rangeID, it.state.Ranges[rangeID], it.options.startTime, it.options.maxItemCount)
+	if IsGone(err) {
+		return true, it.split(rangeID)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	etag := res.Header.Get(api.HEADER_ETAG)
+	if res.StatusCode == http.StatusNotModified || len(result.Documents) == 0 {
+		if etag != "" {
+			it.state.Ranges[rangeID] = etag
+		}
+		return false, nil
+	}
+
+	it.documents = result.Documents
+	it.position = 0
+	it.pending = etag
+	return true, nil
+}
+
+func (it *ChangeFeedIterator) split(rangeID string) error {
+	ranges, err := it.collection.ListPartitionKeyRanges(it.ctx)
+	if err != nil {
+		return err
+	}
+
+	var children []string
+	for _, r := range ranges {
+		for _, parent := range r.Parents {
+			if parent == rangeID {
+				children = append(children, r.ID)
+				it.state.Ranges[r.ID] = it.state.Ranges[rangeID]
+				break
+			}
+		}
+	}
+
+	if len(children) == 0 {
+		return &CosmosError{Code: ErrGone, Message: "partition key range " + rangeID + " is gone and has no children"}
+	}
+
+	delete(it.state.Ranges, rangeID)
+	it.ranges = append(it.ranges[:it.current], append(children, it.ranges[it.current+1:]...)...)
+	return nil
+}
+
+func readChangeFeedPage(ctx context.Context, c Collection, rangeID string, etag string, startTime time.Time, maxItemCount int) (api.ListDocumentsResponse, *http.Response, error) {
+	headers := map[string]string{
+		api.HEADER_A_IM:                api.INCREMENTAL_FEED,
+		api.HEADER_PARTITION_KEY_RANGE: rangeID,
+	}
+
+	if maxItemCount > 0 {
+		headers[api.HEADER_MAX_ITEM_COUNT] = strconv.Itoa(maxItemCount)
+	}
+
+	if etag != "" {
+		headers[api.HEADER_IF_NONE_MATCH] = etag
+	} else if !startTime.IsZero() {
+		headers[api.HEADER_IF_MODIFIED_SINCE] = startTime.UTC().Format(http.TimeFormat)
+	}
+
+	var result api.ListDocumentsResponse
+	res, err := c.database.Client().get(ctx, createDocumentLink(c.database.ID, c.ID, ""), &result, headers)
+	return result, res, err
+}
@@ -0,0 +1,493 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type ChangeFeedHandler func(ctx context.Context, changes []json.RawMessage) error
+
+type ChangeFeedProcessorOption func(*ChangeFeedProcessor)
+
+func ProcessorInstanceName(name string) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.instanceName = name
+	}
+}
+
+func ProcessorLeasePrefix(prefix string) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.leasePrefix = prefix
+	}
+}
+
+func ProcessorLeaseExpiration(expiration time.Duration) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.leaseExpiration = expiration
+	}
+}
+
+func ProcessorLeaseRenewInterval(interval time.Duration) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.leaseRenewInterval = interval
+	}
+}
+
+func ProcessorLeaseAcquireInterval(interval time.Duration) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.leaseAcquireInterval = interval
+	}
+}
+
+func ProcessorFeedPollInterval(interval time.Duration) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.feedPollInterval = interval
+	}
+}
+
+func ProcessorMaxItemCount(maxItemCount int) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.maxItemCount = maxItemCount
+	}
+}
+
+func ProcessorStartFromBeginning() ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.feedOptions.startFromBeginning = true
+	}
+}
+
+func ProcessorStartTime(startTime time.Time) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.feedOptions.startTime = startTime
+	}
+}
+
+func ProcessorErrorHandler(handler func(error)) ChangeFeedProcessorOption {
+	return func(p *ChangeFeedProcessor) {
+		p.errorHandler = handler
+	}
+}
+
+type ChangeFeedProcessor struct {
+	monitored            Collection
+	leases               Collection
+	instanceName         string
+	leasePrefix          string
+	leaseExpiration      time.Duration
+	leaseRenewInterval   time.Duration
+	leaseAcquireInterval time.Duration
+	feedPollInterval     time.Duration
+	maxItemCount         int
+	feedOptions          changeFeedOptions
+	errorHandler         func(error)
+
+	mu      sync.Mutex
+	handler ChangeFeedHandler
+	workers map[string]context.CancelFunc
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type changeFeedLease struct {
+	ID           string    `json:"id"`
+	Etag         string    `json:"_etag,omitempty"`
+	RangeID      string    `json:"partitionKeyRangeId"`
+	Owner        string    `json:"owner"`
+	Continuation string    `json:"continuationToken"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+func (c Collection) ChangeFeedProcessor(leases *Collection, opts ...ChangeFeedProcessorOption) (*ChangeFeedProcessor, error) {
+	if leases == nil {
+		return nil, &CosmosError{Code: ErrBadRequest, Message: "no lease collection specified"}
+	}
+
+	hostname, _ := os.Hostname()
+
+	p := &ChangeFeedProcessor{
+		monitored:            c,
+		leases:               *leases,
+		instanceName:         hostname + "-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		leaseExpiration:      60 * time.Second,
+		leaseRenewInterval:   17 * time.Second,
+		leaseAcquireInterval: 13 * time.Second,
+		feedPollInterval:     5 * time.Second,
+		maxItemCount:         100,
+		workers:              make(map[string]context.CancelFunc),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p, nil
+}
+
+func (d Database) CreateLeaseCollection(ctx context.Context, id string) (*Collection, error) {
+	collection, err := d.CreateCollection(ctx, id, WithPartitionKey(api.PartitionKey{Paths: []string{"/id"}}))
+	if IsConflict(err) {
+		return d.GetCollection(ctx, id)
+	}
+
+	return collection, err
+}
+
+func (p *ChangeFeedProcessor) Start(ctx context.Context, handler ChangeFeedHandler) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return &CosmosError{Code: ErrBadRequest, Message: "change feed processor is already running"}
+	}
+
+	if err := p.bootstrap(ctx); err != nil {
+		return err
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.handler = handler
+
+	p.wg.Add(1)
+	go p.balance(ctx)
+
+	return nil
+}
+
+func (p *ChangeFeedProcessor) Stop() {
+	p.mu.Lock()
+	cancel := p.cancel
+	p.cancel = nil
+	p.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	p.wg.Wait()
+}
+
+func (p *ChangeFeedProcessor) OwnedLeases() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	owned := make([]string, 0, len(p.workers))
+	for id := range p.workers {
+		owned = append(owned, id)
+	}
+	return owned
+}
+
+func (p *ChangeFeedProcessor) bootstrap(ctx context.Context) error {
+	ranges, err := p.monitored.ListPartitionKeyRanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range ranges {
+		lease := changeFeedLease{
+			ID:           p.leaseID(r.ID),
+			RangeID:      r.ID,
+			Continuation: initialChangeFeedEtag(r, p.feedOptions),
+		}
+
+		if err := p.leases.CreateDocument(ctx, lease.ID, lease, false); err != nil && !IsConflict(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *ChangeFeedProcessor) leaseID(rangeID string) string {
+	return p.leasePrefix + p.monitored.database.ID + "_" + p.monitored.ID + ".." + rangeID
+}
+
+func (p *ChangeFeedProcessor) listLeases(ctx context.Context) ([]changeFeedLease, error) {
+	it, err := p.leases.QueryDocuments(ctx, nil, "SELECT * FROM c WHERE STARTSWITH(c.id, @prefix)", QueryParameter{
+		Name:  "@prefix",
+		Value: p.leaseID(""),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var leases []changeFeedLease
+	if err := it.All(&leases); err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+func (p *ChangeFeedProcessor) expired(lease changeFeedLease) bool {
+	return lease.Owner == "" || time.Since(lease.Timestamp) > p.leaseExpiration
+}
+
+func (p *ChangeFeedProcessor) balance(ctx context.Context) {
+	defer p.wg.Done()
+	defer p.releaseAll()
+
+	ticker := time.NewTicker(p.leaseAcquireInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.acquireLeases(ctx); err != nil && ctx.Err() == nil {
+			p.reportError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *ChangeFeedProcessor) acquireLeases(ctx context.Context) error {
+	leases, err := p.listLeases(ctx)
+	if err != nil {
+		return err
+	}
+
+	owners := map[string]int{p.instanceName: 0}
+	var available []changeFeedLease
+	for _, lease := range leases {
+		if p.expired(lease) {
+			available = append(available, lease)
+			continue
+		}
+		owners[lease.Owner]++
+	}
+
+	target := (len(leases) + len(owners) - 1) / len(owners)
+	for owners[p.instanceName] < target && len(available) > 0 {
+		if p.acquire(ctx, available[0]) {
+			owners[p.instanceName]++
+		}
+		available = available[1:]
+	}
+
+	if owners[p.instanceName] >= target {
+		return nil
+	}
+
+	busiest, busiestCount := "", target
+	for owner, count := range owners {
+		if count > busiestCount {
+			busiest, busiestCount = owner, count
+		}
+	}
+
+	if busiest == "" {
+		return nil
+	}
+
+	for _, lease := range leases {
+		if lease.Owner == busiest && !p.expired(lease) {
+			p.acquire(ctx, lease)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (p *ChangeFeedProcessor) acquire(ctx context.Context, lease changeFeedLease) bool {
+	lease.Owner = p.instanceName
+	lease.Timestamp = time.Now().UTC()
+
+	var acquired changeFeedLease
+	if err := p.leases.replaceDocument(ctx, lease.ID, lease.ID, lease, lease.Etag, &acquired); err != nil {
+		if !IsConcurrency(err) && !IsNotFound(err) && ctx.Err() == nil {
+			p.reportError(err)
+		}
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, running := p.workers[lease.ID]; running {
+		return true
+	}
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	p.workers[lease.ID] = cancel
+
+	p.wg.Add(1)
+	go p.process(workerCtx, &acquired)
+
+	return true
+}
+
+func (p *ChangeFeedProcessor) process(ctx context.Context, lease *changeFeedLease) {
+	defer p.wg.Done()
+	defer p.removeWorker(lease.ID)
+
+	renewedAt := time.Now()
+	for ctx.Err() == nil {
+		if time.Since(renewedAt) >= p.leaseRenewInterval {
+			if !p.updateLease(ctx, lease, lease.Continuation) {
+				return
+			}
+			renewedAt = time.Now()
+		}
+
+		result, res, err := readChangeFeedPage(ctx, p.monitored, lease.RangeID, lease.Continuation, p.feedOptions.startTime, p.maxItemCount)
+		if IsGone(err) {
+			if err := p.split(ctx, lease); err != nil {
+				p.reportError(err)
+			}
+			return
+		}
+
+		if err != nil {
+			if ctx.Err() == nil {
+				p.reportError(err)
+			}
+			p.wait(ctx, p.feedPollInterval)
+			continue
+		}
+
+		if len(result.Documents) == 0 {
+			if etag := res.Header.Get(api.HEADER_ETAG); etag != "" && etag != lease.Continuation {
+				if !p.updateLease(ctx, lease, etag) {
+					return
+				}
+				renewedAt = time.Now()
+			}
+
+			p.wait(ctx, p.feedPollInterval)
+			continue
+		}
+
+		if err := p.handler(ctx, result.Documents); err != nil {
+			p.reportError(err)
+			p.wait(ctx, p.feedPollInterval)
+			continue
+		}
+
+		if !p.updateLease(ctx, lease, res.Header.Get(api.HEADER_ETAG)) {
+			return
+		}
+		renewedAt = time.Now()
+	}
+}
+
+func (p *ChangeFeedProcessor) updateLease(ctx context.Context, lease *changeFeedLease, continuation string) bool {
+	for {
+		updated := *lease
+		updated.Continuation = continuation
+		updated.Timestamp = time.Now().UTC()
+
+		var stored changeFeedLease
+		err := p.leases.replaceDocument(ctx, lease.ID, lease.ID, updated, lease.Etag, &stored)
+		if err == nil {
+			*lease = stored
+			return true
+		}
+
+		if !IsConcurrency(err) {
+			if ctx.Err() == nil {
+				p.reportError(err)
+			}
+			return false
+		}
+
+		var current changeFeedLease
+		if err := p.leases.GetDocument(ctx, lease.ID, lease.ID, &current); err != nil || current.Owner != p.instanceName {
+			return false
+		}
+		*lease = current
+	}
+}
+
+func (p *ChangeFeedProcessor) split(ctx context.Context, lease *changeFeedLease) error {
+	ranges, err := p.monitored.ListPartitionKeyRanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range ranges {
+		for _, parent := range r.Parents {
+			if parent != lease.RangeID {
+				continue
+			}
+
+			child := changeFeedLease{
+				ID:           p.leaseID(r.ID),
+				RangeID:      r.ID,
+				Continuation: lease.Continuation,
+			}
+
+			if err := p.leases.CreateDocument(ctx, child.ID, child, false); err != nil && !IsConflict(err) {
+				return err
+			}
+			break
+		}
+	}
+
+	return p.leases.DeleteDocument(ctx, lease.ID, *lease)
+}
+
+func (p *ChangeFeedProcessor) releaseAll() {
+	p.mu.Lock()
+	for _, cancel := range p.workers {
+		cancel()
+	}
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.leaseRenewInterval)
+	defer cancel()
+
+	leases, err := p.listLeases(ctx)
+	if err != nil {
+		p.reportError(err)
+		return
+	}
+
+	for _, lease := range leases {
+		if lease.Owner != p.instanceName {
+			continue
+		}
+
+		lease.Owner = ""
+		if err := p.leases.replaceDocument(ctx, lease.ID, lease.ID, lease, lease.Etag, nil); err != nil && !IsConcurrency(err) {
+			p.reportError(err)
+		}
+	}
+}
+
+func (p *ChangeFeedProcessor) removeWorker(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cancel, ok := p.workers[id]; ok {
+		cancel()
+		delete(p.workers, id)
+	}
+}
+
+func (p *ChangeFeedProcessor) wait(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+func (p *ChangeFeedProcessor) reportError(err error) {
+	if p.errorHandler != nil {
+		p.errorHandler(err)
+	}
+}
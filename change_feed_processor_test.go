@@ -0,0 +1,131 @@
+package cosmos_test
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func TestChangeFeedProcessor(t *testing.T) {
+	server := cosmostest.NewServer(cosmostest.WithPartitionKeyRanges(4))
+	t.Cleanup(server.Close)
+
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, _ := client.CreateDatabase(ctx, "bank")
+	coll, err := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	leases, err := db.CreateLeaseCollection(ctx, "leases")
+	if err != nil {
+		t.Fatalf("failed to create lease collection: %v", err)
+	}
+
+	var mu sync.Mutex
+	seen := map[string]int{}
+	handler := func(ctx context.Context, changes []json.RawMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, change := range changes {
+			var doc account
+			if err := json.Unmarshal(change, &doc); err != nil {
+				return err
+			}
+			seen[doc.Owner]++
+		}
+		return nil
+	}
+
+	processors := make([]*cosmos.ChangeFeedProcessor, 2)
+	for i := range processors {
+		processor, err := coll.ChangeFeedProcessor(leases,
+			cosmos.ProcessorInstanceName("worker"+strconv.Itoa(i)),
+			cosmos.ProcessorStartFromBeginning(),
+			cosmos.ProcessorLeaseAcquireInterval(20*time.Millisecond),
+			cosmos.ProcessorLeaseRenewInterval(50*time.Millisecond),
+			cosmos.ProcessorFeedPollInterval(10*time.Millisecond),
+			cosmos.ProcessorErrorHandler(func(err error) { t.Logf("processor error: %v", err) }),
+		)
+		if err != nil {
+			t.Fatalf("failed to create processor: %v", err)
+		}
+		processors[i] = processor
+
+		if err := processors[i].Start(ctx, handler); err != nil {
+			t.Fatalf("failed to start processor: %v", err)
+		}
+		defer processors[i].Stop()
+	}
+
+	createAccounts := func(from, to int) {
+		for i := from; i < to; i++ {
+			owner := "owner" + strconv.Itoa(i)
+			if err := coll.CreateDocument(ctx, owner, account{Document: cosmos.Document{ID: "1"}, Owner: owner}, false); err != nil {
+				t.Fatalf("failed to create document: %v", err)
+			}
+		}
+	}
+
+	waitFor := func(description string, condition func() bool) {
+		t.Helper()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", description)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	createAccounts(0, 10)
+	waitFor("all changes to be processed", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen) == 10
+	})
+
+	waitFor("leases to be balanced", func() bool {
+		return len(processors[0].OwnedLeases()) == 2 && len(processors[1].OwnedLeases()) == 2
+	})
+
+	if err := server.SplitPartitionKeyRange("bank", "accounts", "0"); err != nil {
+		t.Fatalf("failed to split partition key range: %v", err)
+	}
+
+	createAccounts(10, 20)
+	waitFor("changes after split to be processed", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen) == 20
+	})
+
+	for i, p := range processors {
+		p.Stop()
+		if owned := p.OwnedLeases(); len(owned) != 0 {
+			t.Errorf("expected processor %d to release its leases, still owns %v", i, owned)
+		}
+	}
+}
+
+func TestChangeFeedProcessorWithoutLeases(t *testing.T) {
+	_, coll := newTestCollection(t)
+
+	if _, err := coll.ChangeFeedProcessor(nil); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected bad request without a lease collection, got %v", err)
+	}
+}
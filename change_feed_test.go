@@ -0,0 +1,80 @@
+package cosmos_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func readChanges(t *testing.T, coll *cosmos.Collection, opts ...cosmos.ChangeFeedOption) ([]account, string) {
+	t.Helper()
+
+	it, err := coll.ReadChangeFeed(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("failed to read change feed: %v", err)
+	}
+
+	var changes []account
+	var doc account
+	for it.Next(&doc) {
+		changes = append(changes, doc)
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("failed to iterate change feed: %v", err)
+	}
+
+	return changes, it.Continuation()
+}
+
+func TestReadChangeFeed(t *testing.T) {
+	server := cosmostest.NewServer(cosmostest.WithPartitionKeyRanges(2))
+	t.Cleanup(server.Close)
+
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, _ := client.CreateDatabase(ctx, "bank")
+	coll, err := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		owner := "owner" + strconv.Itoa(i)
+		if err := coll.CreateDocument(ctx, owner, account{Document: cosmos.Document{ID: "1"}, Owner: owner}, false); err != nil {
+			t.Fatalf("failed to create document: %v", err)
+		}
+	}
+
+	if changes, _ := readChanges(t, coll); len(changes) != 0 {
+		t.Errorf("expected no changes when starting from now, got %d", len(changes))
+	}
+
+	changes, continuation := readChanges(t, coll, cosmos.ChangeFeedFromBeginning(), cosmos.ChangeFeedMaxItemCount(2))
+	if len(changes) != 6 {
+		t.Fatalf("expected 6 changes from beginning, got %d", len(changes))
+	}
+
+	updated := changes[0]
+	updated.Balance = 42
+	if err := coll.ReplaceDocument(ctx, updated.Owner, updated); err != nil {
+		t.Fatalf("failed to replace document: %v", err)
+	}
+
+	if err := server.SplitPartitionKeyRange("bank", "accounts", "0"); err != nil {
+		t.Fatalf("failed to split partition key range: %v", err)
+	}
+
+	changes, _ = readChanges(t, coll, cosmos.ChangeFeedFromContinuation(continuation))
+	if len(changes) != 1 || changes[0].Balance != 42 {
+		t.Errorf("expected only the replaced document after resuming, got %+v", changes)
+	}
+}
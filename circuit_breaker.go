@@ -0,0 +1,275 @@
+package cosmos
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+type circuitBreakerOptions struct {
+	failureThreshold  int
+	openTimeout       time.Duration
+	halfOpenProbes    int
+	perPartitionRange bool
+	onStateChange     func(key string, from CircuitState, to CircuitState)
+}
+
+type CircuitBreakerOption func(*circuitBreakerOptions)
+
+func BreakerFailureThreshold(failures int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.failureThreshold = failures
+	}
+}
+
+func BreakerOpenTimeout(timeout time.Duration) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.openTimeout = timeout
+	}
+}
+
+func BreakerHalfOpenProbes(probes int) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.halfOpenProbes = probes
+	}
+}
+
+func BreakerPerPartitionKeyRange(enabled bool) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.perPartitionRange = enabled
+	}
+}
+
+func BreakerOnStateChange(fn func(key string, from CircuitState, to CircuitState)) CircuitBreakerOption {
+	return func(o *circuitBreakerOptions) {
+		o.onStateChange = fn
+	}
+}
+
+type CircuitBreaker struct {
+	options  circuitBreakerOptions
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	changes  []circuitStateChange
+}
+
+type circuitStateChange struct {
+	key  string
+	from CircuitState
+	to   CircuitState
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+func NewCircuitBreaker(opts ...CircuitBreakerOption) *CircuitBreaker {
+	options := circuitBreakerOptions{
+		failureThreshold: 5,
+		openTimeout:      30 * time.Second,
+		halfOpenProbes:   1,
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.failureThreshold < 1 {
+		options.failureThreshold = 1
+	}
+	if options.halfOpenProbes < 1 {
+		options.halfOpenProbes = 1
+	}
+
+	return &CircuitBreaker{
+		options:  options,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+func (b *CircuitBreaker) State(key string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[key]; ok {
+		return c.state
+	}
+
+	return CircuitClosed
+}
+
+func (b *CircuitBreaker) States() map[string]CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]CircuitState, len(b.circuits))
+	for key, c := range b.circuits {
+		states[key] = c.state
+	}
+
+	return states
+}
+
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.circuits = make(map[string]*circuit)
+}
+
+func (b *CircuitBreaker) key(req *http.Request) string {
+	key := req.URL.Host
+	if b.options.perPartitionRange {
+		if rangeID := req.Header.Get(api.HEADER_PARTITION_KEY_RANGE); rangeID != "" {
+			key += "/" + rangeID
+		}
+	}
+
+	return key
+}
+
+func (b *CircuitBreaker) allow(key string) error {
+	defer b.notify()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		return nil
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < b.options.openTimeout {
+			return circuitOpenError(key)
+		}
+		b.transition(key, c, CircuitHalfOpen)
+		c.probes = 0
+		c.successes = 0
+		fallthrough
+
+	case CircuitHalfOpen:
+		if c.probes >= b.options.halfOpenProbes {
+			return circuitOpenError(key)
+		}
+		c.probes++
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) release(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[key]; ok && c.state == CircuitHalfOpen && c.probes > 0 {
+		c.probes--
+	}
+}
+
+func (b *CircuitBreaker) record(key string, failed bool) {
+	defer b.notify()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		if !failed {
+			return
+		}
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+
+	switch c.state {
+	case CircuitClosed:
+		if !failed {
+			c.failures = 0
+			return
+		}
+
+		c.failures++
+		if c.failures >= b.options.failureThreshold {
+			b.open(key, c)
+		}
+
+	case CircuitHalfOpen:
+		if failed {
+			b.open(key, c)
+			return
+		}
+
+		c.successes++
+		if c.successes >= b.options.halfOpenProbes {
+			c.failures = 0
+			b.transition(key, c, CircuitClosed)
+		}
+	}
+}
+
+func (b *CircuitBreaker) open(key string, c *circuit) {
+	c.openedAt = time.Now()
+	b.transition(key, c, CircuitOpen)
+}
+
+func (b *CircuitBreaker) transition(key string, c *circuit, state CircuitState) {
+	if b.options.onStateChange != nil && c.state != state {
+		b.changes = append(b.changes, circuitStateChange{key: key, from: c.state, to: state})
+	}
+
+	c.state = state
+}
+
+func (b *CircuitBreaker) notify() {
+	b.mu.Lock()
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	for _, change := range changes {
+		b.options.onStateChange(change.key, change.from, change.to)
+	}
+}
+
+func circuitOpenError(key string) error {
+	return &CosmosError{Code: ErrCircuitOpen, Message: "circuit breaker is open for " + key}
+}
+
+func isCircuitFailure(res *http.Response, err error) bool {
+	if res == nil {
+		return isTransportError(err)
+	}
+
+	return res.StatusCode == http.StatusRequestTimeout || res.StatusCode >= http.StatusInternalServerError
+}
+
+func (c Client) CircuitBreaker() *CircuitBreaker {
+	return c.breaker
+}
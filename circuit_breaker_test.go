@@ -0,0 +1,116 @@
+package cosmos_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhevron/cosmos"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var mu sync.Mutex
+	var transitions []cosmos.CircuitState
+
+	breaker := cosmos.NewCircuitBreaker(
+		cosmos.BreakerFailureThreshold(3),
+		cosmos.BreakerOpenTimeout(50*time.Millisecond),
+		cosmos.BreakerOnStateChange(func(key string, from cosmos.CircuitState, to cosmos.CircuitState) {
+			mu.Lock()
+			transitions = append(transitions, to)
+			mu.Unlock()
+		}),
+	)
+
+	server, coll := newTestCollection(t, cosmos.WithCircuitBreaker(breaker), cosmos.WithRetries(0))
+	ctx := context.Background()
+
+	endpoint, _ := url.Parse(server.URL)
+	key := endpoint.Host
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "missing", &acc); !cosmos.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if state := breaker.State(key); state != cosmos.CircuitClosed {
+		t.Fatalf("expected client errors to keep the circuit closed, got %v", state)
+	}
+
+	server.Fail(4, http.StatusServiceUnavailable)
+	for i := 0; i < 3; i++ {
+		if err := coll.GetDocument(ctx, "alice", "1", &acc); !cosmos.IsInternalServerError(err) {
+			t.Fatalf("expected server error, got %v", err)
+		}
+	}
+
+	if state := coll.Database().Client().CircuitBreaker().State(key); state != cosmos.CircuitOpen {
+		t.Fatalf("expected the circuit to open after 3 failures, got %v", state)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); !cosmos.IsCircuitOpen(err) {
+		t.Fatalf("expected the open circuit to fail fast, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); !cosmos.IsInternalServerError(err) {
+		t.Fatalf("expected the failing probe to reach the server, got %v", err)
+	}
+	if state := breaker.State(key); state != cosmos.CircuitOpen {
+		t.Fatalf("expected a failed probe to reopen the circuit, got %v", state)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if states := breaker.States(); states[key] != cosmos.CircuitClosed {
+		t.Errorf("expected a successful probe to close the circuit, got %v", states)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	expected := []cosmos.CircuitState{cosmos.CircuitOpen, cosmos.CircuitHalfOpen, cosmos.CircuitOpen, cosmos.CircuitHalfOpen, cosmos.CircuitClosed}
+	if len(transitions) != len(expected) {
+		t.Fatalf("expected transitions %v, got %v", expected, transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("expected transitions %v, got %v", expected, transitions)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresDecodeErrors(t *testing.T) {
+	breaker := cosmos.NewCircuitBreaker(cosmos.BreakerFailureThreshold(2))
+
+	server, coll := newTestCollection(t, cosmos.WithCircuitBreaker(breaker), cosmos.WithRetries(0))
+	ctx := context.Background()
+
+	endpoint, _ := url.Parse(server.URL)
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		var balance int
+		if err := coll.GetDocument(ctx, "alice", "1", &balance); err == nil {
+			t.Fatal("expected decoding the document into an int to fail")
+		}
+	}
+
+	if state := breaker.State(endpoint.Host); state != cosmos.CircuitClosed {
+		t.Errorf("expected decode errors to keep the circuit closed, got %v", state)
+	}
+}
@@ -27,25 +27,44 @@ const (
 )
 
 type Client struct {
-	MaxRetries           int
-	client               *http.Client
-	retryOnStatus        []int
-	populateQueryMetrics bool
-	endpoint             *url.URL
-	key                  Key
-	cache                *cache.Cache
-	tracer               opentracing.Tracer
+	MaxRetries            int
+	MaxConcurrencyRetries int
+	client                *http.Client
+	retryOnStatus         []int
+	retryPolicy           RetryPolicy
+	middleware            []Middleware
+	metrics               MetricsCollector
+	logger                requestLogger
+	limiter               *RateLimiter
+	collectionLimiters    map[string]*RateLimiter
+	breaker               *CircuitBreaker
+	populateQueryMetrics  bool
+	endpoint              *url.URL
+	keys                  *keyRing
+	credential            *tokenCache
+	resourceTokens        map[string]string
+	cache                 *cache.Cache
+	documents             *cache.Cache
+	sessions              *sessionContainer
+	account               *databaseAccountCache
+	locations             *locationCache
+	consistencyLevel      api.ConsistencyLevel
+	tracer                opentracing.Tracer
 }
 
 func Dial(options ...DialOption) (*Client, error) {
 	client := &Client{
-		MaxRetries: 5,
+		MaxRetries:            5,
+		MaxConcurrencyRetries: 3,
 		client: &http.Client{
 			Timeout:   10 * time.Second,
 			Transport: http.DefaultTransport.(*http.Transport).Clone(),
 		},
-		cache:  cache.New(5*time.Minute, 10*time.Minute),
-		tracer: opentracing.NoopTracer{},
+		cache:    cache.New(5*time.Minute, 10*time.Minute),
+		keys:     &keyRing{},
+		sessions: newSessionContainer(),
+		account:  &databaseAccountCache{},
+		tracer:   opentracing.NoopTracer{},
 	}
 
 	for _, option := range options {
@@ -102,6 +121,14 @@ func (c Client) GetDatabase(ctx context.Context, id string) (*Database, error) {
 	return database, nil
 }
 
+func (c Client) Database(id string) *Database {
+	return &Database{
+		Database: api.Database{ID: id},
+		client:   &c,
+		cache:    cache.New(5*time.Minute, 10*time.Minute),
+	}
+}
+
 func (c Client) CreateDatabase(ctx context.Context, id string) (*Database, error) {
 	span, ctx := c.startSpan(ctx, "cosmos.CreateDatabase")
 	defer span.Finish()
@@ -143,6 +170,10 @@ func (c Client) put(ctx context.Context, link string, body interface{}, out inte
 	return c.request(ctx, http.MethodPut, link, body, out, headers)
 }
 
+func (c Client) patch(ctx context.Context, link string, body interface{}, out interface{}, headers map[string]string) (*http.Response, error) {
+	return c.request(ctx, http.MethodPatch, link, body, out, headers)
+}
+
 func (c Client) delete(ctx context.Context, link string, headers map[string]string) (*http.Response, error) {
 	return c.request(ctx, http.MethodDelete, link, nil, nil, headers)
 }
@@ -170,6 +201,11 @@ func (c Client) request(ctx context.Context, method string, link string, body in
 	}
 
 	applyDefaultHeaders(req)
+	if link != "" {
+		for k, v := range requestHeadersFromContext(ctx) {
+			req.Header.Set(k, v)
+		}
+	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
 	}
@@ -178,12 +214,35 @@ func (c Client) request(ctx context.Context, method string, link string, body in
 		req.Header.Set(api.HEADER_QUERY_METRICS, "True")
 	}
 
-	signRequest(c.key, req)
-	return doRequest(ctx, c, req, bodyBytes, out, 0, c.MaxRetries)
+	c.applySessionToken(req)
+	if err := c.applyConsistencyLevel(ctx, req); err != nil {
+		return nil, err
+	}
+
+	r := newRequest(ctx, req, link, bodyBytes)
+	res, err := c.handler(out)(ctx, r)
+
+	var httpRes *http.Response
+	if res != nil {
+		httpReq, reqErr := r.httpRequest(ctx, c.endpoint)
+		if reqErr != nil {
+			return nil, reqErr
+		}
+		httpRes = res.httpResponse(httpReq)
+		if res.raw == nil && err == nil {
+			err = res.decode(httpRes, out)
+		}
+	}
+	if httpRes != nil {
+		recordResponseInfo(ctx, httpRes)
+	}
+
+	return httpRes, err
 }
 
 func (c Client) startSpan(ctx context.Context, operationName string) (opentracing.Span, context.Context) {
 	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, c.tracer, operationName)
+	ctx = withOperation(ctx, operationName)
 
 	ext.DBType.Set(span, "cosmosdb")
 	ext.PeerAddress.Set(span, c.endpoint.String())
@@ -198,7 +257,7 @@ func (c Client) startSpan(ctx context.Context, operationName string) (opentracin
 }
 
 func applyDefaultHeaders(req *http.Request) {
-	if req.Method == http.MethodPost || req.Method == http.MethodPut {
+	if req.Method == http.MethodPost || req.Method == http.MethodPut || req.Method == http.MethodPatch {
 		if req.Header.Get(api.HEADER_CONTENT_TYPE) == "" {
 			req.Header.Set(api.HEADER_CONTENT_TYPE, "application/json")
 		}
@@ -213,6 +272,35 @@ func applyDefaultHeaders(req *http.Request) {
 	req.Header.Set(api.HEADER_VERSION, apiVersion)
 }
 
+func (c Client) authorize(ctx context.Context, req *http.Request, keyIndex int) (Key, error) {
+	if c.credential != nil {
+		return nil, c.signWithToken(ctx, req)
+	}
+
+	if token, ok := c.resourceToken(req.URL.Path); ok {
+		req.Header.Set(api.HEADER_AUTHORIZATION, url.QueryEscape(token))
+		return nil, nil
+	}
+
+	if c.keys.empty() && len(c.resourceTokens) > 0 {
+		return nil, &CosmosError{Code: ErrUnauthorized, Message: "no resource token for " + strings.Trim(req.URL.Path, "/")}
+	}
+
+	key, _ := c.keys.key(keyIndex, isReadRequest(req))
+	signRequest(key, req)
+	return key, nil
+}
+
+func (c Client) RotateKey(key string) error {
+	k, err := ParseKey(key)
+	if err != nil {
+		return err
+	}
+
+	c.keys.rotate(k)
+	return nil
+}
+
 func signRequest(key Key, req *http.Request) {
 	date := req.Header.Get(api.HEADER_DATE)
 	resourceType, resourceID := resourceTypeFromLink(req.URL.Path)
@@ -226,7 +314,7 @@ func signRequest(key Key, req *http.Request) {
 	tokenType := "master"
 	tokenVersion := "1.0"
 	header := "type=" + tokenType + "&ver=" + tokenVersion + "&sig=" + signedPayload
-	req.Header.Add("Authorization", url.QueryEscape(header))
+	req.Header.Set(api.HEADER_AUTHORIZATION, url.QueryEscape(header))
 }
 
 func resourceTypeFromLink(uri string) (string, string) {
@@ -240,6 +328,9 @@ func resourceTypeFromLink(uri string) (string, string) {
 
 	parts := strings.Split(uri, "/")
 	partsLen := len(parts)
+	if partsLen <= 2 {
+		return "", ""
+	}
 
 	if partsLen%2 == 0 {
 		return parts[partsLen-3], strings.Join(parts[1:partsLen-1], "/")
@@ -248,85 +339,166 @@ func resourceTypeFromLink(uri string) (string, string) {
 	}
 }
 
-func doRequest(ctx context.Context, client Client, req *http.Request, body []byte, out interface{}, currentAttempt int, maxRetries int) (*http.Response, error) {
-	span, spanCtx := opentracing.StartSpanFromContext(ctx, "cosmos.HttpRequest")
+type errorBodyDecoder interface {
+	decodeErrorBody(status int) bool
+}
 
-	addSpanTagsFromRequest(spanCtx, req)
+func doRequest(ctx context.Context, client Client, req *http.Request, body []byte, out interface{}, policy RetryPolicy) (*http.Response, error) {
+	limiter := client.rateLimiterFor(req)
+	operation := rateLimiterOperation(ctx, req)
 
-	req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
-	res, err := client.client.Do(req)
-	if err != nil {
-		ext.Error.Set(span, true)
-		span.LogFields(
-			log.String("event", "error"),
-			log.Error(err),
-		)
-		span.Finish()
-		return res, err
+	breaker := client.breaker
+	var breakerKey string
+	if breaker != nil {
+		breakerKey = breaker.key(req)
 	}
-	defer res.Body.Close()
 
-	addSpanTagsFromResponse(spanCtx, res)
+	var waited time.Duration
+	var keyIndex int
+	var tokenRefreshed bool
+	for attempt := 0; ; attempt++ {
+		var reserved float64
+		if limiter != nil {
+			var err error
+			if reserved, err = limiter.reserve(ctx, operation); err != nil {
+				return nil, err
+			}
+		}
 
-	switch res.StatusCode {
-	case http.StatusOK, http.StatusCreated:
-		if res.ContentLength == 0 || out == nil {
-			return res, nil
+		if breaker != nil {
+			if err := breaker.allow(breakerKey); err != nil {
+				if limiter != nil {
+					limiter.settle(operation, reserved, nil)
+				}
+				return nil, err
+			}
 		}
 
-		span.Finish()
-		return res, json.NewDecoder(res.Body).Decode(out)
-	case http.StatusNoContent:
-		span.Finish()
-		return res, nil
-	}
+		req.Header.Set(api.HEADER_DATE, time.Now().UTC().Format(api.TIME_FORMAT))
+		key, err := client.authorize(ctx, req, keyIndex)
+		if err != nil {
+			if limiter != nil {
+				limiter.settle(operation, reserved, nil)
+			}
+			if breaker != nil {
+				breaker.release(breakerKey)
+			}
+			return nil, err
+		}
+
+		started := time.Now()
+		res, done, err := doAttempt(ctx, client, req, body, out)
+		if limiter != nil {
+			limiter.settle(operation, reserved, res)
+		}
+		if breaker != nil {
+			if ctx.Err() != nil {
+				breaker.release(breakerKey)
+			} else {
+				breaker.record(breakerKey, isCircuitFailure(res, err))
+			}
+		}
+		duration := time.Since(started)
+		client.observeRequest(ctx, req, body, res, err, duration)
+		client.logRequest(ctx, req, body, res, err, attempt, duration)
+		if keyIndex > 0 && key != nil && res != nil && res.StatusCode != http.StatusUnauthorized {
+			keyIndex = client.keys.promote(key, isReadRequest(req))
+		}
+		if done {
+			return res, err
+		}
+
+		if res != nil && res.StatusCode == http.StatusUnauthorized {
+			if client.invalidateToken(req) && !tokenRefreshed {
+				tokenRefreshed = true
+				continue
+			}
+			if _, ok := client.keys.key(keyIndex+1, isReadRequest(req)); ok && key != nil {
+				keyIndex++
+				continue
+			}
+		}
+
+		if ctx.Err() == nil {
+			delay, retry := policy.ShouldRetry(RetryAttempt{
+				Attempt:  attempt,
+				Waited:   waited,
+				Request:  req,
+				Response: res,
+				Err:      err,
+			})
+
+			if retry {
+				client.observeRetry(ctx, res, err, attempt+1, delay)
+				if waitErr := waitForRetry(ctx, delay); waitErr != nil {
+					return res, waitErr
+				}
+				waited += delay
+				continue
+			}
+		}
+
+		if res == nil {
+			return nil, err
+		}
+
+		if decoder, ok := out.(errorBodyDecoder); ok && decoder.decodeErrorBody(res.StatusCode) {
+			if b, readErr := ioutil.ReadAll(res.Body); readErr == nil {
+				json.Unmarshal(b, out) // nolint:errcheck
+				res.Body = ioutil.NopCloser(bytes.NewReader(b))
+			}
+		}
 
-	if retry, retryAfter := shouldRetry(client, res, currentAttempt, maxRetries); retry {
-		span.Finish()
-		time.Sleep(retryAfter)
-		return doRequest(ctx, client, req, body, out, currentAttempt+1, maxRetries)
+		return res, errorFromResponse(res)
 	}
+}
+
+func doAttempt(ctx context.Context, client Client, req *http.Request, body []byte, out interface{}) (*http.Response, bool, error) {
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "cosmos.HttpRequest")
+	defer span.Finish()
 
-	err = errorFromResponse(res)
+	addSpanTagsFromRequest(spanCtx, req)
+
+	req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	res, err := client.client.Do(req)
 	if err != nil {
 		ext.Error.Set(span, true)
 		span.LogFields(
 			log.String("event", "error"),
 			log.Error(err),
 		)
+		return nil, false, err
 	}
 
-	span.Finish()
-	return res, err
-}
-
-func shouldRetry(c Client, res *http.Response, currentAttempt int, maxRetries int) (bool, time.Duration) {
-	if currentAttempt >= maxRetries {
-		return false, 0 * time.Millisecond
-	}
+	addSpanTagsFromResponse(spanCtx, res)
 
-	retryAfter := res.Header.Get(api.HEADER_RETRY_AFTER)
-	if retryAfter != "" {
-		if retryAfterMs, err := strconv.Atoi(retryAfter); err == nil {
-			return true, time.Duration(retryAfterMs) * time.Millisecond
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusMultiStatus:
+		b, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(b))
+		if err != nil || len(b) == 0 || out == nil {
+			return res, true, err
 		}
-	}
 
-	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == httpRetryAfter {
-		return true, defaultRetryAfter
+		return res, true, json.Unmarshal(b, out)
+	case http.StatusNoContent, http.StatusNotModified:
+		res.Body.Close()
+		res.Body = http.NoBody
+		return res, true, nil
 	}
 
-	if c.retryOnStatus == nil || len(c.retryOnStatus) == 0 {
-		return false, 0 * time.Millisecond
-	}
+	b, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(b))
 
-	for _, code := range c.retryOnStatus {
-		if res.StatusCode == code {
-			return true, defaultRetryAfter
-		}
-	}
+	ext.Error.Set(span, true)
+	span.LogFields(
+		log.String("event", "error"),
+		log.Int("http.status_code", res.StatusCode),
+	)
 
-	return false, 0 * time.Millisecond
+	return res, false, nil
 }
 
 func addSpanTagsFromRequest(ctx context.Context, req *http.Request) {
@@ -370,33 +542,52 @@ func addSpanTagsFromResponse(ctx context.Context, res *http.Response) {
 }
 
 func errorFromResponse(res *http.Response) error {
-	switch res.StatusCode {
+	code := errorCodeFromStatus(res.StatusCode)
+	switch code {
+	case ErrBadRequest:
+		return &CosmosError{Code: code, Message: errorMessageFromBody(res)}
+
+	case ErrInternalServerError:
+		return &CosmosError{Code: code, Message: "internal server error"}
+	}
+
+	return &CosmosError{Code: code, Message: res.Status} // TODO: Message from response?
+}
+
+func errorCodeFromStatus(status int) ErrorCode {
+	switch status {
 	case http.StatusBadRequest:
-		return &CosmosError{Code: ErrBadRequest, Message: errorMessageFromBody(res)}
+		return ErrBadRequest
 
 	case http.StatusUnauthorized:
-		return &CosmosError{Code: ErrUnauthorized, Message: res.Status} // TODO: Message from response?
+		return ErrUnauthorized
 
 	case http.StatusForbidden:
-		return &CosmosError{Code: ErrForbidden, Message: res.Status} // TODO: Message from response?
+		return ErrForbidden
 
 	case http.StatusNotFound:
-		return &CosmosError{Code: ErrNotFound, Message: res.Status}
+		return ErrNotFound
 
 	case http.StatusRequestTimeout:
-		return &CosmosError{Code: ErrTimeout, Message: res.Status} // TODO: Message from response?
+		return ErrTimeout
 
 	case http.StatusConflict:
-		return &CosmosError{Code: ErrConflict, Message: res.Status} // TODO: Message from response?
+		return ErrConflict
+
+	case http.StatusGone:
+		return ErrGone
 
 	case http.StatusPreconditionFailed:
-		return &CosmosError{Code: ErrConcurrency, Message: res.Status} // TODO: Message from response?
+		return ErrConcurrency
 
 	case http.StatusRequestEntityTooLarge:
-		return &CosmosError{Code: ErrDocumentTooLarge, Message: res.Status} // TODO: Message from response?
+		return ErrDocumentTooLarge
+
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
 	}
 
-	return &CosmosError{Code: ErrInternalServerError, Message: "internal server error"}
+	return ErrInternalServerError
 }
 
 func errorMessageFromBody(res *http.Response) string {
@@ -404,6 +595,7 @@ func errorMessageFromBody(res *http.Response) string {
 	if err != nil {
 		return err.Error()
 	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(b))
 
 	contentType := res.Header.Get(api.HEADER_CONTENT_TYPE)
 	switch contentType {
@@ -2,8 +2,10 @@ package cosmos
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"strings"
 
 	"github.com/opentracing/opentracing-go"
@@ -17,12 +19,15 @@ type Collection struct {
 	database *Database
 }
 
-func (c Collection) ListDocuments(ctx context.Context) (*DocumentIterator, error) {
+func (c Collection) ListDocuments(ctx context.Context, opts ...RequestOption) (*DocumentIterator, error) {
 	span, ctx := c.startCollectionSpan(ctx, "cosmos.ListDocuments")
 	defer span.Finish()
 
+	headers := make(map[string]string)
+	ctx = applyRequestOptions(ctx, headers, opts)
+
 	var listResult api.ListDocumentsResponse
-	res, err := c.database.Client().get(ctx, createDocumentLink(c.database.ID, c.ID, ""), &listResult, nil)
+	res, err := c.database.Client().get(ctx, createDocumentLink(c.database.ID, c.ID, ""), &listResult, headers)
 	if err != nil {
 		return nil, err
 	}
@@ -30,19 +35,81 @@ func (c Collection) ListDocuments(ctx context.Context) (*DocumentIterator, error
 	return newDocumentIterator(ctx, c.database.Client(), res, nil, listResult), nil
 }
 
-func (c Collection) GetDocument(ctx context.Context, partitionKey interface{}, id string, out interface{}) error {
+func (c Collection) GetDocument(ctx context.Context, partitionKey interface{}, id string, out interface{}, opts ...RequestOption) error {
 	span, ctx := c.startCollectionSpan(ctx, "cosmos.GetDocument")
 	defer span.Finish()
 
 	headers := map[string]string{
 		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
-	_, err := c.database.Client().get(ctx, createDocumentLink(c.database.ID, c.ID, id), out, headers)
-	return err
+	client := c.database.Client()
+	link := createDocumentLink(c.database.ID, c.ID, id)
+	if client.documents == nil {
+		_, err := client.get(ctx, link, out, headers)
+		return err
+	}
+
+	key := documentCacheKey(link, headers[api.HEADER_PARTITION_KEY])
+	cached, found := client.cachedDocument(key)
+	if found {
+		headers[api.HEADER_IF_NONE_MATCH] = cached.etag
+	}
+
+	var body json.RawMessage
+	res, err := client.get(ctx, link, &body, headers)
+	if err != nil {
+		client.invalidateDocument(key)
+		return err
+	}
+
+	if res.StatusCode == http.StatusNotModified && !found {
+		delete(headers, api.HEADER_IF_NONE_MATCH)
+		if res, err = client.get(ctx, link, &body, headers); err != nil {
+			return err
+		}
+	}
+
+	if res.StatusCode == http.StatusNotModified {
+		span.SetTag("cosmos.cache_hit", true)
+		body = cached.body
+	} else {
+		etag := res.Header.Get(api.HEADER_ETAG)
+		if etag == "" {
+			etag = documentEtagFromJSON(body)
+		}
+		client.cacheDocument(key, etag, body)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(body, out)
 }
 
-func (c Collection) CreateDocument(ctx context.Context, partitionKey interface{}, document interface{}, upsert bool) error {
+func (c Collection) GetDocumentIfChanged(ctx context.Context, partitionKey interface{}, id string, etag string, out interface{}, opts ...RequestOption) (bool, error) {
+	span, ctx := c.startDocumentSpan(ctx, "cosmos.GetDocumentIfChanged", id)
+	defer span.Finish()
+
+	headers := map[string]string{
+		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
+	}
+	ctx = applyRequestOptions(ctx, headers, opts)
+	if etag != "" {
+		headers[api.HEADER_IF_NONE_MATCH] = etag
+	}
+
+	res, err := c.database.Client().get(ctx, createDocumentLink(c.database.ID, c.ID, id), out, headers)
+	if err != nil {
+		return false, err
+	}
+
+	return res.StatusCode != http.StatusNotModified, nil
+}
+
+func (c Collection) CreateDocument(ctx context.Context, partitionKey interface{}, document interface{}, upsert bool, opts ...RequestOption) error {
 	span, ctx := c.startCollectionSpan(ctx, "cosmos.CreateDocument")
 	defer span.Finish()
 
@@ -51,13 +118,23 @@ func (c Collection) CreateDocument(ctx context.Context, partitionKey interface{}
 	}
 	if upsert {
 		headers[api.HEADER_IS_UPSERT] = "True"
+
+		if documentID, err := DocumentID(document); err == nil {
+			c.database.Client().invalidateDocument(documentCacheKey(createDocumentLink(c.database.ID, c.ID, documentID), headers[api.HEADER_PARTITION_KEY]))
+		}
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
-	_, err := c.database.Client().post(ctx, createDocumentLink(c.database.ID, c.ID, ""), document, nil, headers)
+	document, err := applyTimeToLive(document, opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.database.Client().post(ctx, createDocumentLink(c.database.ID, c.ID, ""), document, nil, headers)
 	return err
 }
 
-func (c Collection) ReplaceDocument(ctx context.Context, partitionKey interface{}, document interface{}) error {
+func (c Collection) ReplaceDocument(ctx context.Context, partitionKey interface{}, document interface{}, opts ...RequestOption) error {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return err
@@ -66,15 +143,66 @@ func (c Collection) ReplaceDocument(ctx context.Context, partitionKey interface{
 	span, ctx := c.startDocumentSpan(ctx, "cosmos.ReplaceDOcument", documentID)
 	defer span.Finish()
 
+	return c.replaceDocument(ctx, partitionKey, documentID, document, documentIfMatch(document, opts), nil, opts...)
+}
+
+func (c Collection) UpdateDocument(ctx context.Context, partitionKey interface{}, id string, document interface{}, update func() error, opts ...RequestOption) error {
+	span, ctx := c.startDocumentSpan(ctx, "cosmos.UpdateDocument", id)
+	defer span.Finish()
+
 	headers := map[string]string{
 		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
+	delete(headers, api.HEADER_IF_MATCH)
+
+	for attempt := 0; ; attempt++ {
+		resetDocument(document)
+
+		res, err := c.database.Client().get(ctx, createDocumentLink(c.database.ID, c.ID, id), document, headers)
+		if err != nil {
+			return err
+		}
 
-	_, err = c.database.Client().put(ctx, createDocumentLink(c.database.ID, c.ID, documentID), document, nil, headers)
+		if err := update(); err != nil {
+			return err
+		}
+
+		etag := res.Header.Get(api.HEADER_ETAG)
+		if etag == "" {
+			etag = DocumentEtag(document)
+		}
+
+		err = c.replaceDocument(ctx, partitionKey, id, document, etag, nil, opts...)
+		if !IsConcurrency(err) || attempt >= c.database.Client().MaxConcurrencyRetries {
+			span.SetTag("cosmos.attempts", attempt+1)
+			return err
+		}
+	}
+}
+
+func (c Collection) replaceDocument(ctx context.Context, partitionKey interface{}, documentID string, document interface{}, etag string, out interface{}, opts ...RequestOption) error {
+	headers := map[string]string{
+		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
+	}
+	ctx = applyRequestOptions(ctx, headers, opts)
+	if etag != "" {
+		headers[api.HEADER_IF_MATCH] = etag
+	}
+
+	document, err := applyTimeToLive(document, opts)
+	if err != nil {
+		return err
+	}
+
+	link := createDocumentLink(c.database.ID, c.ID, documentID)
+	c.database.Client().invalidateDocument(documentCacheKey(link, headers[api.HEADER_PARTITION_KEY]))
+
+	_, err = c.database.Client().put(ctx, link, document, out, headers)
 	return err
 }
 
-func (c Collection) DeleteDocument(ctx context.Context, partitionKey interface{}, document interface{}) error {
+func (c Collection) DeleteDocument(ctx context.Context, partitionKey interface{}, document interface{}, opts ...RequestOption) error {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return err
@@ -86,12 +214,61 @@ func (c Collection) DeleteDocument(ctx context.Context, partitionKey interface{}
 	headers := map[string]string{
 		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
 	}
+	if etag := documentIfMatch(document, opts); etag != "" {
+		headers[api.HEADER_IF_MATCH] = etag
+	}
+	ctx = applyRequestOptions(ctx, headers, opts)
+
+	link := createDocumentLink(c.database.ID, c.ID, documentID)
+	c.database.Client().invalidateDocument(documentCacheKey(link, headers[api.HEADER_PARTITION_KEY]))
+
+	_, err = c.database.Client().delete(ctx, link, headers)
+	return err
+}
+
+func (c Collection) PatchDocument(ctx context.Context, partitionKey interface{}, id string, out interface{}, ops ...PatchOperation) error {
+	span, ctx := c.startDocumentSpan(ctx, "cosmos.PatchDocument", id)
+	defer span.Finish()
+
+	return c.patchDocument(ctx, partitionKey, id, "", out, ops)
+}
+
+func (c Collection) ConditionalPatchDocument(ctx context.Context, partitionKey interface{}, id string, condition string, out interface{}, ops ...PatchOperation) error {
+	span, ctx := c.startDocumentSpan(ctx, "cosmos.ConditionalPatchDocument", id)
+	defer span.Finish()
+
+	ext.DBStatement.Set(span, condition)
+
+	return c.patchDocument(ctx, partitionKey, id, condition, out, ops)
+}
+
+func (c Collection) patchDocument(ctx context.Context, partitionKey interface{}, id string, condition string, out interface{}, ops []PatchOperation) error {
+	if len(ops) == 0 {
+		return &CosmosError{Code: ErrBadRequest, Message: "no patch operations specified"}
+	}
+
+	headers := map[string]string{
+		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
+		api.HEADER_CONTENT_TYPE:  "application/json_patch+json",
+	}
 
-	_, err = c.database.Client().delete(ctx, createDocumentLink(c.database.ID, c.ID, documentID), headers)
+	req := api.PatchDocumentRequest{
+		Condition:  condition,
+		Operations: ops,
+	}
+
+	link := createDocumentLink(c.database.ID, c.ID, id)
+	c.database.Client().invalidateDocument(documentCacheKey(link, headers[api.HEADER_PARTITION_KEY]))
+
+	_, err := c.database.Client().patch(ctx, link, req, out, headers)
 	return err
 }
 
 func (c Collection) QueryDocuments(ctx context.Context, partitionKey interface{}, query string, params ...api.QueryParameter) (*DocumentIterator, error) {
+	return c.QueryDocumentsWithOptions(ctx, partitionKey, query, params)
+}
+
+func (c Collection) QueryDocumentsWithOptions(ctx context.Context, partitionKey interface{}, query string, params []api.QueryParameter, opts ...RequestOption) (*DocumentIterator, error) {
 	span, ctx := c.startCollectionSpan(ctx, "cosmos.QueryDocuments")
 	defer span.Finish()
 
@@ -106,6 +283,8 @@ func (c Collection) QueryDocuments(ctx context.Context, partitionKey interface{}
 		headers[api.HEADER_PARTITION_KEY] = makePartitionKeyHeaderValue(partitionKey)
 	}
 
+	ctx = applyRequestOptions(ctx, headers, opts)
+
 	queryParams := []api.QueryParameter{}
 	for _, p := range params {
 		if strings.Contains(query, p.Name) {
@@ -130,7 +309,7 @@ func (c Collection) QueryDocuments(ctx context.Context, partitionKey interface{}
 	return newDocumentIterator(ctx, c.database.Client(), res, apiQuery, queryResult), nil
 }
 
-func (c Collection) ListAttachments(ctx context.Context, partitionKey interface{}, document interface{}) ([]*Attachment, error) {
+func (c Collection) ListAttachments(ctx context.Context, partitionKey interface{}, document interface{}, opts ...RequestOption) ([]*Attachment, error) {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return nil, err
@@ -142,6 +321,7 @@ func (c Collection) ListAttachments(ctx context.Context, partitionKey interface{
 	headers := map[string]string{
 		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
 	var res api.ListAttachmentsResponse
 	if _, err := c.database.Client().get(ctx, createAttachmentLink(c.database.ID, c.ID, documentID, ""), &res, headers); err != nil {
@@ -158,7 +338,7 @@ func (c Collection) ListAttachments(ctx context.Context, partitionKey interface{
 	return attachments, nil
 }
 
-func (c Collection) GetAttachment(ctx context.Context, partitionKey interface{}, document interface{}, id string) (*Attachment, error) {
+func (c Collection) GetAttachment(ctx context.Context, partitionKey interface{}, document interface{}, id string, opts ...RequestOption) (*Attachment, error) {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return nil, err
@@ -170,6 +350,7 @@ func (c Collection) GetAttachment(ctx context.Context, partitionKey interface{},
 	headers := map[string]string{
 		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
 	var attachment api.Attachment
 	if _, err := c.database.Client().get(ctx, createAttachmentLink(c.database.ID, c.ID, documentID, id), &attachment, headers); err != nil {
@@ -181,7 +362,7 @@ func (c Collection) GetAttachment(ctx context.Context, partitionKey interface{},
 	}, nil
 }
 
-func (c Collection) CreateAttachmentFromReader(ctx context.Context, partitionKey interface{}, document interface{}, id string, contentType string, reader io.Reader) (*Attachment, error) {
+func (c Collection) CreateAttachmentFromReader(ctx context.Context, partitionKey interface{}, document interface{}, id string, contentType string, reader io.Reader, opts ...RequestOption) (*Attachment, error) {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return nil, err
@@ -195,6 +376,7 @@ func (c Collection) CreateAttachmentFromReader(ctx context.Context, partitionKey
 		api.HEADER_CONTENT_TYPE:  contentType,
 		"Slug":                   id,
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
 	content, err := ioutil.ReadAll(reader)
 	if err != nil {
@@ -211,7 +393,7 @@ func (c Collection) CreateAttachmentFromReader(ctx context.Context, partitionKey
 	}, nil
 }
 
-func (c Collection) CreateAttachmentFromMedia(ctx context.Context, partitionKey interface{}, document interface{}, id string, contentType string, media string) (*Attachment, error) {
+func (c Collection) CreateAttachmentFromMedia(ctx context.Context, partitionKey interface{}, document interface{}, id string, contentType string, media string, opts ...RequestOption) (*Attachment, error) {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return nil, err
@@ -223,6 +405,7 @@ func (c Collection) CreateAttachmentFromMedia(ctx context.Context, partitionKey
 	headers := map[string]string{
 		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
 	attachment := api.Attachment{
 		ID:          id,
@@ -239,7 +422,7 @@ func (c Collection) CreateAttachmentFromMedia(ctx context.Context, partitionKey
 	}, nil
 }
 
-func (c Collection) ReplaceAttachmentFromReader(ctx context.Context, partitionKey interface{}, document interface{}, id string, contentType string, reader io.Reader) (*Attachment, error) {
+func (c Collection) ReplaceAttachmentFromReader(ctx context.Context, partitionKey interface{}, document interface{}, id string, contentType string, reader io.Reader, opts ...RequestOption) (*Attachment, error) {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return nil, err
@@ -253,6 +436,7 @@ func (c Collection) ReplaceAttachmentFromReader(ctx context.Context, partitionKe
 		api.HEADER_CONTENT_TYPE:  contentType,
 		"Slug":                   id,
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
 	content, err := ioutil.ReadAll(reader)
 	if err != nil {
@@ -269,7 +453,7 @@ func (c Collection) ReplaceAttachmentFromReader(ctx context.Context, partitionKe
 	}, nil
 }
 
-func (c Collection) ReplaceAttachmentFromMedia(ctx context.Context, partitionKey interface{}, document interface{}, id string, contentType string, media string) (*Attachment, error) {
+func (c Collection) ReplaceAttachmentFromMedia(ctx context.Context, partitionKey interface{}, document interface{}, id string, contentType string, media string, opts ...RequestOption) (*Attachment, error) {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return nil, err
@@ -281,6 +465,7 @@ func (c Collection) ReplaceAttachmentFromMedia(ctx context.Context, partitionKey
 	headers := map[string]string{
 		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
 	attachment := api.Attachment{
 		ID:          id,
@@ -297,7 +482,7 @@ func (c Collection) ReplaceAttachmentFromMedia(ctx context.Context, partitionKey
 	}, nil
 }
 
-func (c Collection) DeleteAttachment(ctx context.Context, partitionKey interface{}, document interface{}, id string) error {
+func (c Collection) DeleteAttachment(ctx context.Context, partitionKey interface{}, document interface{}, id string, opts ...RequestOption) error {
 	documentID, err := DocumentID(document)
 	if err != nil {
 		return err
@@ -309,6 +494,7 @@ func (c Collection) DeleteAttachment(ctx context.Context, partitionKey interface
 	headers := map[string]string{
 		api.HEADER_PARTITION_KEY: makePartitionKeyHeaderValue(partitionKey),
 	}
+	ctx = applyRequestOptions(ctx, headers, opts)
 
 	_, err = c.database.Client().delete(ctx, createAttachmentLink(c.database.ID, c.ID, documentID, id), headers)
 	return err
@@ -0,0 +1,307 @@
+package cosmos_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+type account struct {
+	cosmos.Document
+
+	Owner   string `json:"owner"`
+	Balance int    `json:"balance"`
+}
+
+func newTestCollection(t *testing.T, opts ...cosmos.DialOption) (*cosmostest.Server, *cosmos.Collection) {
+	t.Helper()
+
+	server := cosmostest.NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(opts...)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	db, err := client.CreateDatabase(context.Background(), "bank")
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	coll, err := db.CreateCollection(context.Background(), "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	return server, coll
+}
+
+func TestReplaceDocumentIfMatchDocumentEtag(t *testing.T) {
+	_, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var first, second account
+	if err := coll.GetDocument(ctx, "alice", "1", &first); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if err := coll.GetDocument(ctx, "alice", "1", &second); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+
+	first.Balance = 10
+	if err := coll.ReplaceDocument(ctx, "alice", first); err != nil {
+		t.Fatalf("failed to replace document: %v", err)
+	}
+
+	second.Balance = 20
+	if err := coll.ReplaceDocument(ctx, "alice", second, cosmos.WithIfMatchDocumentEtag()); !cosmos.IsConcurrency(err) {
+		t.Errorf("expected concurrency error for stale etag, got %v", err)
+	}
+
+	if err := coll.DeleteDocument(ctx, "alice", second, cosmos.WithIfMatchDocumentEtag()); !cosmos.IsConcurrency(err) {
+		t.Errorf("expected concurrency error for stale delete, got %v", err)
+	}
+
+	if err := coll.ReplaceDocument(ctx, "alice", second); err != nil {
+		t.Errorf("expected replace without If-Match to ignore the stale etag, got %v", err)
+	}
+
+	if err := coll.DeleteDocument(ctx, "alice", second); err != nil {
+		t.Errorf("expected delete without If-Match to ignore the stale etag, got %v", err)
+	}
+}
+
+func TestUpdateDocumentRetriesOnConflict(t *testing.T) {
+	_, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	attempts := 0
+	var doc account
+	err := coll.UpdateDocument(ctx, "alice", "1", &doc, func() error {
+		attempts++
+		if attempts == 1 {
+			competing := doc
+			competing.Balance = 100
+			if err := coll.ReplaceDocument(ctx, "alice", competing); err != nil {
+				t.Fatalf("failed to replace competing document: %v", err)
+			}
+		}
+
+		doc.Balance += 5
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update document: %v", err)
+	}
+
+	if attempts != 2 || doc.Balance != 105 {
+		t.Errorf("expected 2 attempts and balance 105, got %d attempts and balance %d", attempts, doc.Balance)
+	}
+}
+
+func TestUpdateDocumentOptions(t *testing.T) {
+	var mu sync.Mutex
+	var reads []http.Header
+
+	record := func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			if req.Operation == "UpdateDocument" && req.Method == http.MethodGet {
+				mu.Lock()
+				reads = append(reads, req.Header.Clone())
+				mu.Unlock()
+			}
+			return next(ctx, req)
+		}
+	}
+
+	_, coll := newTestCollection(t, cosmos.WithMiddleware(record))
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var doc account
+	err := coll.UpdateDocument(ctx, "alice", "1", &doc, func() error {
+		doc.Balance = 5
+		return nil
+	}, cosmos.WithReadConsistency(api.ConsistencyLevelEventual), cosmos.WithIfMatch(`"stale"`))
+	if err != nil {
+		t.Fatalf("expected the read etag to take precedence over WithIfMatch, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(reads) != 1 {
+		t.Fatalf("expected a single read, got %d", len(reads))
+	}
+	if got := reads[0].Get(api.HEADER_CONSISTENCY_LEVEL); got != string(api.ConsistencyLevelEventual) {
+		t.Errorf("expected the read to use the request options, got consistency level %q", got)
+	}
+	if got := reads[0].Get(api.HEADER_IF_MATCH); got != "" {
+		t.Errorf("expected the read not to send If-Match, got %q", got)
+	}
+}
+
+func TestGetDocumentIfChanged(t *testing.T) {
+	_, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var doc account
+	if err := coll.GetDocument(ctx, "alice", "1", &doc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+
+	var unchanged account
+	changed, err := coll.GetDocumentIfChanged(ctx, "alice", "1", doc.Etag, &unchanged)
+	if err != nil || changed || unchanged.ID != "" {
+		t.Errorf("expected not modified, got changed=%v err=%v doc=%+v", changed, err, unchanged)
+	}
+
+	doc.Balance = 50
+	if err := coll.ReplaceDocument(ctx, "alice", doc); err != nil {
+		t.Fatalf("failed to replace document: %v", err)
+	}
+
+	var updated account
+	changed, err = coll.GetDocumentIfChanged(ctx, "alice", "1", doc.Etag, &updated)
+	if err != nil || !changed || updated.Balance != 50 {
+		t.Errorf("expected modified document, got changed=%v err=%v doc=%+v", changed, err, updated)
+	}
+}
+
+func TestDocumentCacheRevalidates(t *testing.T) {
+	server, coll := newTestCollection(t, cosmos.WithDocumentCache(time.Minute))
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice", Balance: 1}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var doc account
+	for i := 0; i < 2; i++ {
+		if err := coll.GetDocument(ctx, "alice", "1", &doc); err != nil || doc.Balance != 1 {
+			t.Fatalf("unexpected cached read: doc=%+v err=%v", doc, err)
+		}
+	}
+
+	other, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	db, _ := other.GetDatabase(ctx, "bank")
+	otherColl, _ := db.GetCollection(ctx, "accounts")
+
+	doc.Balance = 2
+	if err := otherColl.ReplaceDocument(ctx, "alice", doc); err != nil {
+		t.Fatalf("failed to replace document: %v", err)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "1", &doc); err != nil || doc.Balance != 2 {
+		t.Errorf("expected revalidated document, got doc=%+v err=%v", doc, err)
+	}
+}
+
+func TestDocumentCacheNotModifiedWithoutEntry(t *testing.T) {
+	var mu sync.Mutex
+	notModified := 1
+
+	stale := func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			mu.Lock()
+			inject := req.Operation == "GetDocument" && notModified > 0
+			if inject {
+				notModified--
+			}
+			mu.Unlock()
+
+			if inject {
+				return &cosmos.Response{ResponseInfo: cosmos.ResponseInfo{StatusCode: http.StatusNotModified}}, nil
+			}
+			return next(ctx, req)
+		}
+	}
+
+	_, coll := newTestCollection(t, cosmos.WithDocumentCache(time.Minute), cosmos.WithMiddleware(stale))
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice", Balance: 1}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var doc account
+	if err := coll.GetDocument(ctx, "alice", "1", &doc); err != nil || doc.Balance != 1 {
+		t.Errorf("expected a fresh read after an unexpected 304, got doc=%+v err=%v", doc, err)
+	}
+}
+
+func TestPatchDocument(t *testing.T) {
+	_, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice", Balance: 10}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var doc map[string]interface{}
+	err := coll.PatchDocument(ctx, "alice", "1", &doc,
+		cosmos.PatchIncrement("/balance", 5),
+		cosmos.PatchAdd("/tags", []string{"vip"}),
+		cosmos.PatchAdd("/tags/-", "gold"),
+		cosmos.PatchMove("/tags", "/labels"),
+	)
+	if err != nil {
+		t.Fatalf("failed to patch document: %v", err)
+	}
+
+	if doc["balance"] != float64(15) || doc["tags"] != nil || len(doc["labels"].([]interface{})) != 2 {
+		t.Errorf("unexpected patched document: %+v", doc)
+	}
+
+	err = coll.ConditionalPatchDocument(ctx, "alice", "1", "FROM c WHERE c.balance > 100", nil, cosmos.PatchSet("/balance", 0))
+	if !cosmos.IsConcurrency(err) {
+		t.Errorf("expected failed precondition, got %v", err)
+	}
+}
+
+func TestPatchOperationJSON(t *testing.T) {
+	for _, tt := range []struct {
+		op   cosmos.PatchOperation
+		want string
+	}{
+		{cosmos.PatchSet("/nickname", nil), `{"op":"set","path":"/nickname","value":null}`},
+		{cosmos.PatchReplace("/active", false), `{"op":"replace","path":"/active","value":false}`},
+		{cosmos.PatchRemove("/labels"), `{"op":"remove","path":"/labels"}`},
+		{cosmos.PatchMove("/tags", "/labels"), `{"op":"move","path":"/labels","from":"/tags"}`},
+	} {
+		b, err := json.Marshal(tt.op)
+		if err != nil {
+			t.Fatalf("failed to marshal patch operation: %v", err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, b)
+		}
+	}
+}
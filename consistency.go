@@ -0,0 +1,119 @@
+package cosmos
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const (
+	databaseAccountExpiration    = 5 * time.Minute
+	databaseAccountRetryInterval = 30 * time.Second
+)
+
+var consistencyStrength = map[api.ConsistencyLevel]int{
+	api.ConsistencyLevelEventual:         0,
+	api.ConsistencyLevelConsistentPrefix: 1,
+	api.ConsistencyLevelSession:          2,
+	api.ConsistencyLevelBoundedStaleness: 3,
+	api.ConsistencyLevelStrong:           4,
+}
+
+type databaseAccountCache struct {
+	mu        sync.Mutex
+	account   *api.DatabaseAccount
+	fetchedAt time.Time
+	failedAt  time.Time
+	err       error
+}
+
+func (c Client) GetDatabaseAccount(ctx context.Context) (*api.DatabaseAccount, error) {
+	span, ctx := c.startSpan(ctx, "cosmos.GetDatabaseAccount")
+	defer span.Finish()
+
+	var account api.DatabaseAccount
+	if _, err := c.get(ctx, "", &account, nil); err != nil {
+		c.account.mu.Lock()
+		c.account.failedAt = time.Now()
+		c.account.err = err
+		c.account.mu.Unlock()
+		return nil, err
+	}
+
+	c.account.mu.Lock()
+	c.account.account = &account
+	c.account.fetchedAt = time.Now()
+	c.account.mu.Unlock()
+
+	if c.locations != nil {
+		c.locations.update(&account)
+	}
+
+	return &account, nil
+}
+
+func (c Client) databaseAccount(ctx context.Context) (*api.DatabaseAccount, error) {
+	c.account.mu.Lock()
+	account, fetchedAt, failedAt, lastErr := c.account.account, c.account.fetchedAt, c.account.failedAt, c.account.err
+	c.account.mu.Unlock()
+
+	if account != nil && time.Since(fetchedAt) < databaseAccountExpiration {
+		return account, nil
+	}
+
+	if time.Since(failedAt) < databaseAccountRetryInterval {
+		if account != nil {
+			return account, nil
+		}
+		return nil, lastErr
+	}
+
+	return c.GetDatabaseAccount(context.WithValue(ctx, responseInfoKey{}, (*ResponseInfo)(nil)))
+}
+
+func (c Client) applyConsistencyLevel(ctx context.Context, req *http.Request) error {
+	if c.consistencyLevel != "" && req.Header.Get(api.HEADER_CONSISTENCY_LEVEL) == "" && isReadRequest(req) {
+		if _, ok := collectionLinkFromPath(req.URL.Path); ok {
+			req.Header.Set(api.HEADER_CONSISTENCY_LEVEL, string(c.consistencyLevel))
+		}
+	}
+
+	level := api.ConsistencyLevel(req.Header.Get(api.HEADER_CONSISTENCY_LEVEL))
+	if level == "" {
+		return nil
+	}
+
+	if err := validateConsistencyLevel(level); err != nil {
+		return err
+	}
+
+	if c.credential == nil && c.keys.empty() {
+		return nil
+	}
+
+	account, err := c.databaseAccount(ctx)
+	if err != nil {
+		return nil
+	}
+
+	accountLevel := account.ConsistencyPolicy.DefaultConsistencyLevel
+	if strength, ok := consistencyStrength[accountLevel]; ok && consistencyStrength[level] > strength {
+		return &CosmosError{
+			Code:    ErrBadRequest,
+			Message: "consistency level " + string(level) + " is stronger than the account default " + string(accountLevel),
+		}
+	}
+
+	return nil
+}
+
+func validateConsistencyLevel(level api.ConsistencyLevel) error {
+	if _, ok := consistencyStrength[level]; !ok {
+		return &CosmosError{Code: ErrBadRequest, Message: "invalid consistency level " + string(level)}
+	}
+
+	return nil
+}
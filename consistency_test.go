@@ -0,0 +1,143 @@
+package cosmos_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func TestConsistencyLevel(t *testing.T) {
+	server, coll := newTestCollection(t, cosmos.WithConsistencyLevel(api.ConsistencyLevelEventual))
+	ctx := context.Background()
+
+	databaseAccount, err := coll.Database().Client().GetDatabaseAccount(ctx)
+	if err != nil {
+		t.Fatalf("failed to get database account: %v", err)
+	}
+	if databaseAccount.ConsistencyPolicy.DefaultConsistencyLevel != api.ConsistencyLevelSession {
+		t.Errorf("expected account default Session, got %q", databaseAccount.ConsistencyPolicy.DefaultConsistencyLevel)
+	}
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_CONSISTENCY_LEVEL); sent != "" {
+		t.Errorf("expected writes not to send a consistency level, got %q", sent)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_CONSISTENCY_LEVEL); sent != string(api.ConsistencyLevelEventual) {
+		t.Errorf("expected client default Eventual, got %q", sent)
+	}
+
+	if _, err := coll.QueryDocumentsWithOptions(ctx, "alice", "SELECT * FROM c", nil, cosmos.WithReadConsistency(api.ConsistencyLevelSession)); err != nil {
+		t.Fatalf("failed to query documents: %v", err)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_CONSISTENCY_LEVEL); sent != string(api.ConsistencyLevelSession) {
+		t.Errorf("expected per-operation Session, got %q", sent)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc, cosmos.WithReadConsistency(api.ConsistencyLevelStrong)); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected Strong to be rejected against a Session account, got %v", err)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc, cosmos.WithReadConsistency("Linearizable")); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected an unknown consistency level to be rejected, got %v", err)
+	}
+}
+
+func TestConsistencyLevelDialOption(t *testing.T) {
+	server := cosmostest.NewServer(cosmostest.WithConsistencyLevel(api.ConsistencyLevelStrong))
+	t.Cleanup(server.Close)
+
+	if _, err := server.Client(cosmos.WithConsistencyLevel("Linearizable")); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected an unknown consistency level to be rejected, got %v", err)
+	}
+
+	client, err := server.Client(cosmos.WithConsistencyLevel(api.ConsistencyLevelBoundedStaleness))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, _ := client.CreateDatabase(ctx, "bank")
+	coll, err := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	if _, err := coll.ListDocuments(ctx); err != nil {
+		t.Errorf("expected BoundedStaleness to be allowed against a Strong account, got %v", err)
+	}
+	if _, err := coll.ListDocuments(ctx, cosmos.WithReadConsistency(api.ConsistencyLevelStrong)); err != nil {
+		t.Errorf("expected Strong to be allowed against a Strong account, got %v", err)
+	}
+}
+
+func TestConsistencyLevelWithoutDatabaseAccount(t *testing.T) {
+	server, coll := newTestCollection(t, cosmos.WithRetries(0))
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	server.Fail(1, http.StatusServiceUnavailable)
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc, cosmos.WithReadConsistency(api.ConsistencyLevelEventual)); err != nil {
+		t.Fatalf("expected the read to proceed without the database account, got %v", err)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_CONSISTENCY_LEVEL); sent != string(api.ConsistencyLevelEventual) {
+		t.Errorf("expected Eventual to be sent, got %q", sent)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc, cosmos.WithReadConsistency(api.ConsistencyLevelStrong)); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected the service to reject Strong, got %v", err)
+	}
+}
+
+func TestConsistencyLevelWithResourceTokens(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	user, err := coll.Database().CreateUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	permission, err := user.CreatePermission(ctx, "accounts", api.PermissionModeRead, "dbs/bank/colls/accounts")
+	if err != nil {
+		t.Fatalf("failed to create permission: %v", err)
+	}
+
+	endpoint, _ := url.Parse(server.URL)
+	client, err := cosmos.Dial(
+		cosmos.WithEndpoint(endpoint),
+		cosmos.WithResourceTokens(map[string]string{permission.Resource: permission.Token}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	scoped, err := client.Database("bank").GetCollection(ctx, "accounts")
+	if err != nil {
+		t.Fatalf("failed to get collection: %v", err)
+	}
+
+	var acc account
+	if err := scoped.GetDocument(ctx, "alice", "1", &acc, cosmos.WithReadConsistency(api.ConsistencyLevelEventual)); err != nil {
+		t.Errorf("expected a resource token read with a consistency level to succeed, got %v", err)
+	}
+}
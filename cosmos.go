@@ -7,6 +7,7 @@ import (
 
 type DateTime = api.DateTime
 type Document = api.Document
+type PartitionKeyRange = api.PartitionKeyRange
 type QueryParameter = api.QueryParameter
 
 func Select(fields ...string) query.Query {
@@ -0,0 +1,176 @@
+package cosmosotel
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/zhevron/cosmos"
+)
+
+const instrumentationName = "github.com/zhevron/cosmos/cosmosotel"
+
+var (
+	dbSystem              = attribute.Key("db.system")
+	dbName                = attribute.Key("db.name")
+	dbOperation           = attribute.Key("db.operation")
+	dbCosmosContainer     = attribute.Key("db.cosmosdb.container")
+	dbCosmosStatusCode    = attribute.Key("db.cosmosdb.status_code")
+	dbCosmosSubStatusCode = attribute.Key("db.cosmosdb.sub_status_code")
+	dbCosmosRequestCharge = attribute.Key("db.cosmosdb.request_charge")
+	dbCosmosItemCount     = attribute.Key("db.cosmosdb.item_count")
+	dbCosmosActivityID    = attribute.Key("db.cosmosdb.activity_id")
+	dbCosmosPartitionKey  = attribute.Key("db.cosmosdb.partition_key")
+)
+
+type config struct {
+	tracerProvider        trace.TracerProvider
+	meterProvider         metric.MeterProvider
+	partitionKeyAttribute bool
+}
+
+type Option func(*config)
+
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+func WithPartitionKeyAttribute(enabled bool) Option {
+	return func(c *config) {
+		c.partitionKeyAttribute = enabled
+	}
+}
+
+func WithOpenTelemetry(opts ...Option) cosmos.DialOption {
+	return func(c *cosmos.Client) error {
+		middleware, err := Middleware(opts...)
+		if err != nil {
+			return err
+		}
+
+		return cosmos.WithMiddleware(middleware)(c)
+	}
+}
+
+func Middleware(opts ...Option) (cosmos.Middleware, error) {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tracer := cfg.tracerProvider.Tracer(instrumentationName)
+	meter := cfg.meterProvider.Meter(instrumentationName)
+
+	duration, err := meter.Float64Histogram(
+		"db.client.operation.duration",
+		metric.WithDescription("Duration of Cosmos DB requests."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	requestCharge, err := meter.Float64Histogram(
+		"db.client.cosmosdb.operation.request_charge",
+		metric.WithDescription("Request units consumed by Cosmos DB requests."),
+		metric.WithUnit("{request_unit}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			operation := operationName(req)
+			attrs := requestAttributes(req, operation)
+
+			ctx, span := tracer.Start(ctx, spanName(operation, attrs),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			if cfg.partitionKeyAttribute && req.PartitionKey != "" {
+				span.SetAttributes(dbCosmosPartitionKey.String(req.PartitionKey))
+			}
+
+			start := time.Now()
+			res, err := next(ctx, req)
+			elapsed := time.Since(start)
+
+			if res != nil {
+				attrs = append(attrs, dbCosmosStatusCode.Int(res.StatusCode))
+				span.SetAttributes(
+					dbCosmosStatusCode.Int(res.StatusCode),
+					dbCosmosSubStatusCode.Int(res.Substatus),
+					dbCosmosRequestCharge.Float64(res.RequestCharge),
+					dbCosmosItemCount.Int(res.ItemCount),
+					dbCosmosActivityID.String(res.ActivityID),
+				)
+				requestCharge.Record(ctx, res.RequestCharge, metric.WithAttributes(attrs...))
+			}
+
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			duration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(attrs...))
+
+			return res, err
+		}
+	}, nil
+}
+
+func operationName(req *cosmos.Request) string {
+	if req.Operation != "" {
+		return req.Operation
+	}
+
+	return req.Method + " " + req.ResourceType
+}
+
+func spanName(operation string, attrs []attribute.KeyValue) string {
+	for _, attr := range attrs {
+		if attr.Key == dbCosmosContainer {
+			return operation + " " + attr.Value.AsString()
+		}
+	}
+
+	return operation
+}
+
+func requestAttributes(req *cosmos.Request, operation string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		dbSystem.String("cosmosdb"),
+		dbOperation.String(operation),
+	}
+
+	segments := strings.Split(strings.Trim(req.Link, "/"), "/")
+	if len(segments) >= 2 && segments[0] == "dbs" {
+		attrs = append(attrs, dbName.String(segments[1]))
+	}
+	if len(segments) >= 4 && segments[2] == "colls" {
+		attrs = append(attrs, dbCosmosContainer.String(segments[3]))
+	}
+
+	return attrs
+}
@@ -0,0 +1,171 @@
+package cosmosotel_test
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmosotel"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+type account struct {
+	cosmos.Document
+
+	Owner string `json:"owner"`
+}
+
+func TestOpenTelemetry(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	server := cosmostest.NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(cosmosotel.WithOpenTelemetry(
+		cosmosotel.WithTracerProvider(tracerProvider),
+		cosmosotel.WithMeterProvider(meterProvider),
+	))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := client.CreateDatabase(ctx, "bank")
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	coll, err := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "missing", &acc); !cosmos.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(ended))
+	}
+
+	create := ended[2]
+	if create.Name() != "CreateDocument accounts" {
+		t.Errorf("unexpected span name %q", create.Name())
+	}
+
+	attrs := attribute.NewSet(create.Attributes()...)
+	for key, want := range map[attribute.Key]attribute.Value{
+		"db.system":                  attribute.StringValue("cosmosdb"),
+		"db.name":                    attribute.StringValue("bank"),
+		"db.cosmosdb.container":      attribute.StringValue("accounts"),
+		"db.operation":               attribute.StringValue("CreateDocument"),
+		"db.cosmosdb.status_code":    attribute.IntValue(201),
+		"db.cosmosdb.request_charge": attribute.Float64Value(1),
+	} {
+		if got, ok := attrs.Value(key); !ok || got != want {
+			t.Errorf("expected %s=%v, got %v", key, want.Emit(), got.Emit())
+		}
+	}
+
+	if _, ok := attrs.Value("db.cosmosdb.partition_key"); ok {
+		t.Error("expected the partition key attribute to be opt-in")
+	}
+
+	if failed := ended[3]; failed.Status().Code.String() != "Error" {
+		t.Errorf("expected failed request span to have error status, got %v", failed.Status())
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &metrics); err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+
+	histograms := map[string]uint64{}
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if h, ok := m.Data.(metricdata.Histogram[float64]); ok {
+				for _, point := range h.DataPoints {
+					histograms[m.Name] += point.Count
+					if _, ok := point.Attributes.Value("db.cosmosdb.partition_key"); ok {
+						t.Errorf("expected %s to have no partition key attribute", m.Name)
+					}
+				}
+			}
+		}
+	}
+
+	if histograms["db.client.operation.duration"] != 4 || histograms["db.client.cosmosdb.operation.request_charge"] != 4 {
+		t.Errorf("unexpected histogram counts %v", histograms)
+	}
+}
+
+func TestOpenTelemetryPartitionKeyAttribute(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	server := cosmostest.NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(cosmosotel.WithOpenTelemetry(
+		cosmosotel.WithTracerProvider(tracerProvider),
+		cosmosotel.WithMeterProvider(meterProvider),
+		cosmosotel.WithPartitionKeyAttribute(true),
+	))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, _ := client.CreateDatabase(ctx, "bank")
+	coll, err := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	ended := spans.Ended()
+	attrs := attribute.NewSet(ended[len(ended)-1].Attributes()...)
+	if got, ok := attrs.Value("db.cosmosdb.partition_key"); !ok || got.AsString() != `["alice"]` {
+		t.Errorf("expected the partition key attribute on the span, got %v", got.Emit())
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &metrics); err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if h, ok := m.Data.(metricdata.Histogram[float64]); ok {
+				for _, point := range h.DataPoints {
+					if _, ok := point.Attributes.Value("db.cosmosdb.partition_key"); ok {
+						t.Errorf("expected %s to have no partition key attribute", m.Name)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package cosmostest
+
+import (
+	"net/http"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+var consistencyStrength = map[api.ConsistencyLevel]int{
+	api.ConsistencyLevelEventual:         0,
+	api.ConsistencyLevelConsistentPrefix: 1,
+	api.ConsistencyLevelSession:          2,
+	api.ConsistencyLevelBoundedStaleness: 3,
+	api.ConsistencyLevelStrong:           4,
+}
+
+func (s *Server) serveDatabaseAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writable, readable := s.accountLocations()
+
+	writeJSON(w, http.StatusOK, api.DatabaseAccount{
+		ID:                "localhost",
+		WritableLocations: writable,
+		ReadableLocations: readable,
+		ConsistencyPolicy: api.ConsistencyPolicy{
+			DefaultConsistencyLevel: s.consistencyLevel,
+		},
+	})
+}
+
+func (s *Server) checkConsistencyLevel(w http.ResponseWriter, r *http.Request) bool {
+	level := api.ConsistencyLevel(r.Header.Get(api.HEADER_CONSISTENCY_LEVEL))
+	if level == "" {
+		return true
+	}
+
+	strength, ok := consistencyStrength[level]
+	if !ok || strength > consistencyStrength[s.consistencyLevel] {
+		writeError(w, http.StatusBadRequest, "The requested consistency level is invalid or stronger than the account default.")
+		return false
+	}
+
+	return true
+}
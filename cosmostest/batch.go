@@ -0,0 +1,261 @@
+package cosmostest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const maxBatchOperations = 100
+
+type batchOperation struct {
+	OperationType api.BatchOperationType `json:"operationType"`
+	ID            string                 `json:"id"`
+	ResourceBody  json.RawMessage        `json:"resourceBody"`
+	IfMatch       string                 `json:"ifMatch"`
+	PartitionKey  string                 `json:"partitionKey"`
+}
+
+type batch struct {
+	server       *Server
+	coll         *collection
+	self         string
+	partitionKey []interface{}
+	staged       map[string]resource
+	order        []string
+}
+
+func (s *Server) executeBatch(w http.ResponseWriter, r *http.Request, coll *collection, segments []string, body []byte) {
+	var ops []batchOperation
+	if err := json.Unmarshal(body, &ops); err != nil || len(ops) == 0 {
+		writeError(w, http.StatusBadRequest, "The batch request is invalid.")
+		return
+	}
+
+	if len(ops) > maxBatchOperations {
+		writeError(w, http.StatusBadRequest, "The batch request exceeds the maximum number of operations.")
+		return
+	}
+
+	partitionKeys := make([][]interface{}, len(ops))
+	if rangeID := r.Header.Get(api.HEADER_PARTITION_KEY_RANGE); rangeID != "" && r.Header.Get(api.HEADER_PARTITION_KEY) == "" {
+		kr := coll.rangeByID(rangeID)
+		if kr == nil {
+			w.Header().Set(api.HEADER_SUBSTATUS, substatusPartitionKeyRangeGone)
+			writeError(w, http.StatusGone, "The requested partition key range is gone.")
+			return
+		}
+
+		for i, op := range ops {
+			opPartitionKey, err := parsePartitionKeyHeader(op.PartitionKey)
+			if err != nil || len(opPartitionKey) != len(coll.paths) {
+				writeError(w, http.StatusBadRequest, "Each operation in a partition key range batch must specify a valid partition key.")
+				return
+			}
+
+			if coll.rangeOfKey(opPartitionKey) != kr {
+				w.Header().Set(api.HEADER_SUBSTATUS, substatusPartitionKeyRangeGone)
+				writeError(w, http.StatusGone, "The partition key does not belong to the requested partition key range.")
+				return
+			}
+			partitionKeys[i] = opPartitionKey
+		}
+	} else {
+		partitionKey, ok := requirePartitionKey(w, r, coll, nil)
+		if !ok {
+			return
+		}
+
+		for i := range ops {
+			partitionKeys[i] = partitionKey
+		}
+	}
+
+	b := &batch{
+		server: s,
+		coll:   coll,
+		self:   strings.Join(segments, "/"),
+		staged: make(map[string]resource),
+	}
+
+	atomic := !strings.EqualFold(r.Header.Get(api.HEADER_BATCH_ATOMIC), "False")
+	continueOnError := strings.EqualFold(r.Header.Get(api.HEADER_BATCH_CONTINUE), "True")
+
+	results := make([]api.BatchOperationResult, len(ops))
+	status := http.StatusOK
+	for i, op := range ops {
+		b.partitionKey = partitionKeys[i]
+		opStatus, document := b.apply(op)
+		results[i] = api.BatchOperationResult{
+			StatusCode:    opStatus,
+			RequestCharge: 1,
+		}
+
+		if document != nil {
+			results[i].Etag = document.etag()
+			results[i].ResourceBody, _ = json.Marshal(document)
+		}
+
+		if opStatus < http.StatusBadRequest {
+			if !atomic {
+				b.commit()
+			}
+			continue
+		}
+
+		b.rollback()
+		if !atomic && continueOnError {
+			status = http.StatusMultiStatus
+			continue
+		}
+
+		for j := range results {
+			if j != i && (atomic || j > i) {
+				results[j] = api.BatchOperationResult{StatusCode: http.StatusFailedDependency}
+			}
+		}
+
+		status = opStatus
+		if !atomic {
+			status = http.StatusMultiStatus
+		}
+		break
+	}
+
+	if atomic && status == http.StatusOK {
+		b.commit()
+	}
+	writeJSON(w, status, results)
+}
+
+func (b *batch) get(key string) (resource, bool) {
+	if document, ok := b.staged[key]; ok {
+		return document, document != nil
+	}
+	return b.coll.documents.get(key)
+}
+
+func (b *batch) stage(key string, document resource) {
+	if _, ok := b.staged[key]; !ok {
+		b.order = append(b.order, key)
+	}
+	b.staged[key] = document
+}
+
+func (b *batch) commit() {
+	for _, key := range b.order {
+		if document := b.staged[key]; document != nil {
+			b.coll.documents.set(key, document)
+			continue
+		}
+
+		b.coll.documents.remove(key)
+		delete(b.coll.attachments, key)
+	}
+
+	b.rollback()
+}
+
+func (b *batch) rollback() {
+	b.staged = make(map[string]resource)
+	b.order = nil
+}
+
+func (b *batch) apply(op batchOperation) (int, resource) {
+	switch op.OperationType {
+	case api.BatchOperationCreate, api.BatchOperationUpsert, api.BatchOperationReplace:
+		var document resource
+		if err := json.Unmarshal(op.ResourceBody, &document); err != nil || document == nil || document.id() == "" {
+			return http.StatusBadRequest, nil
+		}
+
+		if op.OperationType == api.BatchOperationReplace && op.ID != "" && op.ID != document.id() {
+			return http.StatusBadRequest, nil
+		}
+
+		if !partitionKeyEqual(b.coll.partitionKey(document), b.partitionKey) {
+			return http.StatusBadRequest, nil
+		}
+
+		key := b.coll.documentKey(b.partitionKey, document.id())
+		existing, exists := b.get(key)
+		status := http.StatusOK
+		switch {
+		case exists && op.OperationType == api.BatchOperationCreate:
+			return http.StatusConflict, nil
+		case !exists && op.OperationType == api.BatchOperationReplace:
+			return http.StatusNotFound, nil
+		case !exists:
+			status = http.StatusCreated
+		}
+
+		if exists && op.IfMatch != "" && op.IfMatch != "*" && op.IfMatch != existing.etag() {
+			return http.StatusPreconditionFailed, nil
+		}
+
+		b.server.stamp(document, b.self+"/"+document.id()+"/", existing)
+		b.stage(key, document)
+		return status, document
+
+	case api.BatchOperationRead:
+		existing, exists := b.get(b.coll.documentKey(b.partitionKey, op.ID))
+		if !exists {
+			return http.StatusNotFound, nil
+		}
+		return http.StatusOK, existing
+
+	case api.BatchOperationDelete:
+		key := b.coll.documentKey(b.partitionKey, op.ID)
+		existing, exists := b.get(key)
+		if !exists {
+			return http.StatusNotFound, nil
+		}
+
+		if op.IfMatch != "" && op.IfMatch != "*" && op.IfMatch != existing.etag() {
+			return http.StatusPreconditionFailed, nil
+		}
+
+		b.stage(key, nil)
+		return http.StatusNoContent, nil
+
+	case api.BatchOperationPatch:
+		key := b.coll.documentKey(b.partitionKey, op.ID)
+		existing, exists := b.get(key)
+		if !exists {
+			return http.StatusNotFound, nil
+		}
+
+		if op.IfMatch != "" && op.IfMatch != "*" && op.IfMatch != existing.etag() {
+			return http.StatusPreconditionFailed, nil
+		}
+
+		var req api.PatchDocumentRequest
+		if err := json.Unmarshal(op.ResourceBody, &req); err != nil || len(req.Operations) == 0 {
+			return http.StatusBadRequest, nil
+		}
+
+		if req.Condition != "" {
+			matches, err := executeQuery(api.Query{Query: "SELECT * " + req.Condition}, []resource{existing}, b.server.userDefinedFunctions(b.coll))
+			if err != nil {
+				return http.StatusBadRequest, nil
+			}
+
+			if len(matches) == 0 {
+				return http.StatusPreconditionFailed, nil
+			}
+		}
+
+		document, err := applyPatch(existing, req.Operations)
+		if err != nil || document.id() != op.ID || !partitionKeyEqual(b.coll.partitionKey(document), b.partitionKey) {
+			return http.StatusBadRequest, nil
+		}
+
+		b.server.stamp(document, existing["_self"].(string), existing)
+		b.stage(key, document)
+		return http.StatusOK, document
+	}
+
+	return http.StatusBadRequest, nil
+}
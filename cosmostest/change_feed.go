@@ -0,0 +1,211 @@
+package cosmostest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+)
+
+const (
+	rangeBuckets = 64
+
+	substatusPartitionKeyRangeGone = "1002"
+)
+
+type keyRange struct {
+	id      string
+	min     int
+	max     int
+	parents []string
+}
+
+func (r *keyRange) resource() resource {
+	parents := r.parents
+	if parents == nil {
+		parents = []string{}
+	}
+
+	return resource{
+		"id":           r.id,
+		"minInclusive": rangeBoundary(r.min),
+		"maxExclusive": rangeBoundary(r.max),
+		"parents":      parents,
+	}
+}
+
+func rangeBoundary(bucket int) string {
+	switch bucket {
+	case 0:
+		return ""
+	case rangeBuckets:
+		return "FF"
+	}
+	return fmt.Sprintf("%02X", bucket)
+}
+
+func (c *collection) rangeByID(id string) *keyRange {
+	for _, r := range c.ranges {
+		if r.id == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (c *collection) rangeOf(document resource) *keyRange {
+	return c.rangeOfKey(c.partitionKey(document))
+}
+
+func (c *collection) rangeOfKey(partitionKey []interface{}) *keyRange {
+	bucket, _ := strconv.ParseInt(cosmos.EffectivePartitionKey(partitionKey...)[:2], 16, 32)
+
+	for _, r := range c.ranges {
+		if int(bucket) >= r.min && int(bucket) < r.max {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *Server) SplitPartitionKeyRange(databaseID string, collectionID string, rangeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, ok := s.children[databaseID]
+	if !ok {
+		return fmt.Errorf("database %q does not exist", databaseID)
+	}
+
+	coll, ok := db.collections[collectionID]
+	if !ok {
+		return fmt.Errorf("collection %q does not exist", collectionID)
+	}
+
+	for i, r := range coll.ranges {
+		if r.id != rangeID {
+			continue
+		}
+
+		if r.max-r.min < 2 {
+			return fmt.Errorf("partition key range %q cannot be split further", rangeID)
+		}
+
+		parents := append(append([]string{}, r.parents...), r.id)
+		middle := r.min + (r.max-r.min)/2
+		left := &keyRange{id: strconv.Itoa(coll.nextRangeID), min: r.min, max: middle, parents: parents}
+		right := &keyRange{id: strconv.Itoa(coll.nextRangeID + 1), min: middle, max: r.max, parents: parents}
+		coll.nextRangeID += 2
+
+		coll.ranges = append(coll.ranges[:i], append([]*keyRange{left, right}, coll.ranges[i+1:]...)...)
+		return nil
+	}
+
+	return fmt.Errorf("partition key range %q does not exist", rangeID)
+}
+
+func (s *Server) servePartitionKeyRanges(w http.ResponseWriter, r *http.Request, segments []string) {
+	_, coll, ok := s.lookupCollection(w, segments)
+	if !ok {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ranges := make([]resource, len(coll.ranges))
+	for i, kr := range coll.ranges {
+		ranges[i] = kr.resource()
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_rid":               coll.resource["_rid"],
+		"PartitionKeyRanges": ranges,
+		"_count":             len(ranges),
+	})
+}
+
+func (s *Server) changeFeed(w http.ResponseWriter, r *http.Request, coll *collection) {
+	var kr *keyRange
+	if rangeID := r.Header.Get(api.HEADER_PARTITION_KEY_RANGE); rangeID != "" {
+		if kr = coll.rangeByID(rangeID); kr == nil {
+			w.Header().Set(api.HEADER_SUBSTATUS, substatusPartitionKeyRangeGone)
+			writeError(w, http.StatusGone, "The requested partition key range is gone.")
+			return
+		}
+	}
+
+	since := int64(0)
+	switch ifNoneMatch := r.Header.Get(api.HEADER_IF_NONE_MATCH); ifNoneMatch {
+	case "":
+	case "*":
+		since = s.sequence
+	default:
+		lsn, err := strconv.ParseInt(strings.Trim(ifNoneMatch, "\""), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid change feed continuation")
+			return
+		}
+		since = lsn
+	}
+
+	var modifiedSince int64
+	if header := r.Header.Get(api.HEADER_IF_MODIFIED_SINCE); header != "" {
+		t, err := time.Parse(http.TimeFormat, header)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid If-Modified-Since header")
+			return
+		}
+		modifiedSince = t.Unix()
+	}
+
+	var changes []resource
+	for _, d := range coll.documents.all() {
+		if kr != nil && coll.rangeOf(d) != kr {
+			continue
+		}
+
+		if lsn(d) > since && d["_ts"].(int64) >= modifiedSince {
+			changes = append(changes, d)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return lsn(changes[i]) < lsn(changes[j])
+	})
+
+	limit := s.pageSize
+	if maxItemCount, err := strconv.Atoi(r.Header.Get(api.HEADER_MAX_ITEM_COUNT)); err == nil && maxItemCount > 0 {
+		limit = maxItemCount
+	}
+
+	if limit > 0 && len(changes) > limit {
+		changes = changes[:limit]
+	}
+
+	if len(changes) == 0 {
+		w.Header().Set(api.HEADER_ETAG, strconv.Quote(strconv.FormatInt(since, 10)))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set(api.HEADER_ETAG, strconv.Quote(strconv.FormatInt(lsn(changes[len(changes)-1]), 10)))
+	s.writeDocuments(w, r, coll, changes)
+}
+
+func lsn(document resource) int64 {
+	switch v := document["_lsn"].(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,241 @@
+package cosmostest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+func applyPatch(document resource, ops []api.PatchOperation) (resource, error) {
+	var node interface{} = normalize(map[string]interface{}(document))
+
+	for _, op := range ops {
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		value := normalize(op.Value)
+		switch op.Op {
+		case api.PatchOperationAdd:
+			node, err = updatePointer(node, tokens, addValue(value))
+
+		case api.PatchOperationSet:
+			node, err = updatePointer(node, tokens, setValue(value))
+
+		case api.PatchOperationReplace:
+			node, err = updatePointer(node, tokens, replaceValue(value))
+
+		case api.PatchOperationRemove:
+			node, err = updatePointer(node, tokens, removeValue)
+
+		case api.PatchOperationIncrement:
+			node, err = updatePointer(node, tokens, incrementValue(value))
+
+		case api.PatchOperationMove:
+			var from []string
+			if from, err = parsePointer(op.From); err != nil {
+				return nil, err
+			}
+
+			if value, err = readPointer(node, from); err != nil {
+				return nil, err
+			}
+
+			if node, err = updatePointer(node, from, removeValue); err != nil {
+				return nil, err
+			}
+
+			node, err = updatePointer(node, tokens, setValue(value))
+
+		default:
+			err = fmt.Errorf("unsupported patch operation %q", op.Op)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resource(node.(map[string]interface{})), nil
+}
+
+func parsePointer(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") || len(path) < 2 {
+		return nil, fmt.Errorf("invalid patch path %q", path)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func readPointer(node interface{}, tokens []string) (interface{}, error) {
+	for _, t := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			value, ok := n[t]
+			if !ok {
+				return nil, fmt.Errorf("path /%s does not exist", strings.Join(tokens, "/"))
+			}
+			node = value
+
+		case []interface{}:
+			i, err := arrayIndex(t, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+
+		default:
+			return nil, fmt.Errorf("path /%s does not exist", strings.Join(tokens, "/"))
+		}
+	}
+
+	return node, nil
+}
+
+type pointerUpdate func(parent interface{}, key string) (interface{}, error)
+
+func updatePointer(node interface{}, tokens []string, update pointerUpdate) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(node, tokens[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path segment %q does not exist", tokens[0])
+		}
+
+		updated, err := updatePointer(child, tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = updated
+		return n, nil
+
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := updatePointer(n[i], tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("path segment %q does not exist", tokens[0])
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+func addValue(value interface{}) pointerUpdate {
+	return func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+
+		case []interface{}:
+			if key == "-" {
+				return append(p, value), nil
+			}
+
+			i, err := arrayIndex(key, len(p))
+			if err != nil {
+				return nil, err
+			}
+
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		}
+
+		return nil, fmt.Errorf("cannot add %q to a non-container value", key)
+	}
+}
+
+func setValue(value interface{}) pointerUpdate {
+	return func(parent interface{}, key string) (interface{}, error) {
+		if p, ok := parent.([]interface{}); ok {
+			i, err := arrayIndex(key, len(p))
+			if err != nil {
+				return nil, err
+			}
+
+			if i == len(p) {
+				return append(p, value), nil
+			}
+			p[i] = value
+			return p, nil
+		}
+
+		return addValue(value)(parent, key)
+	}
+}
+
+func replaceValue(value interface{}) pointerUpdate {
+	return func(parent interface{}, key string) (interface{}, error) {
+		if _, err := readPointer(parent, []string{key}); err != nil {
+			return nil, err
+		}
+		return setValue(value)(parent, key)
+	}
+}
+
+func removeValue(parent interface{}, key string) (interface{}, error) {
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := p[key]; !ok {
+			return nil, fmt.Errorf("path segment %q does not exist", key)
+		}
+		delete(p, key)
+		return p, nil
+
+	case []interface{}:
+		i, err := arrayIndex(key, len(p)-1)
+		if err != nil {
+			return nil, err
+		}
+		return append(p[:i], p[i+1:]...), nil
+	}
+
+	return nil, fmt.Errorf("cannot remove %q from a non-container value", key)
+}
+
+func incrementValue(value interface{}) pointerUpdate {
+	return func(parent interface{}, key string) (interface{}, error) {
+		delta, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("increment value for %q must be a number", key)
+		}
+
+		current, err := readPointer(parent, []string{key})
+		if err != nil {
+			return setValue(delta)(parent, key)
+		}
+
+		n, ok := current.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot increment non-numeric value at %q", key)
+		}
+		return setValue(n+delta)(parent, key)
+	}
+}
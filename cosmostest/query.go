@@ -0,0 +1,993 @@
+package cosmostest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type undefinedValue struct{}
+
+var undefined = undefinedValue{}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenParam
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsLetter(r) || r == '_' || r == '@':
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			kind := tokenIdent
+			if r == '@' {
+				kind = tokenParam
+			}
+			tokens = append(tokens, token{kind: kind, text: string(runes[start:i])})
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i])})
+
+		case r == '\'' || r == '"':
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string literal")
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String()})
+
+		default:
+			text := string(r)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "!=", "<>", "<=", ">=":
+					text = two
+				}
+			}
+
+			if !strings.Contains("()[]{},.:*=!<>-", text[:1]) {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+
+			i += len([]rune(text))
+			tokens = append(tokens, token{kind: tokenPunct, text: text})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+type expression interface {
+	eval(root interface{}) interface{}
+}
+
+type projection struct {
+	name string
+	expr expression
+}
+
+type ordering struct {
+	expr       expression
+	descending bool
+}
+
+type selectQuery struct {
+	top         int
+	star        bool
+	value       expression
+	projections []projection
+	alias       string
+	where       expression
+	orderBy     []ordering
+}
+
+func executeQuery(q api.Query, documents []resource, udfs map[string]UserDefinedFunc) ([]interface{}, error) {
+	params := make(map[string]interface{}, len(q.Parameters))
+	for _, p := range q.Parameters {
+		params[p.Name] = normalize(p.Value)
+	}
+
+	tokens, err := tokenize(q.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, params: params, udfs: udfs}
+	sq, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, root := range p.roots {
+		if root != sq.alias {
+			return nil, fmt.Errorf("identifier '%s' could not be resolved", root)
+		}
+	}
+
+	matched := make([]interface{}, 0, len(documents))
+	for _, d := range documents {
+		doc := normalize(d)
+		if sq.where == nil || sq.where.eval(doc) == true {
+			matched = append(matched, doc)
+		}
+	}
+
+	if len(sq.orderBy) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, o := range sq.orderBy {
+				c := compareOrder(o.expr.eval(matched[i]), o.expr.eval(matched[j]))
+				if c == 0 {
+					continue
+				}
+				if o.descending {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	results := make([]interface{}, 0, len(matched))
+	for _, doc := range matched {
+		if sq.top >= 0 && len(results) >= sq.top {
+			break
+		}
+
+		switch {
+		case sq.star:
+			results = append(results, doc)
+
+		case sq.value != nil:
+			if v := sq.value.eval(doc); v != undefined {
+				results = append(results, v)
+			}
+
+		default:
+			out := make(map[string]interface{}, len(sq.projections))
+			for _, p := range sq.projections {
+				if v := p.expr.eval(doc); v != undefined {
+					out[p.name] = v
+				}
+			}
+			results = append(results, out)
+		}
+	}
+
+	return results, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	params map[string]interface{}
+	udfs   map[string]UserDefinedFunc
+	roots  []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) punct(text string) bool {
+	t := p.peek()
+	if t.kind == tokenPunct && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) isUserDefinedFunctionCall() bool {
+	if p.pos+2 >= len(p.tokens) {
+		return false
+	}
+
+	dot, name, paren := p.tokens[p.pos], p.tokens[p.pos+1], p.tokens[p.pos+2]
+	return dot.kind == tokenPunct && dot.text == "." && name.kind == tokenIdent && paren.kind == tokenPunct && paren.text == "("
+}
+
+func (p *parser) expect(text string) error {
+	if p.punct(text) || p.keyword(text) {
+		return nil
+	}
+	return fmt.Errorf("syntax error, expected '%s' near '%s'", text, p.peek().text)
+}
+
+func (p *parser) parseQuery() (*selectQuery, error) {
+	q := &selectQuery{top: -1}
+
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+
+	if p.keyword("TOP") {
+		t := p.next()
+		top, err := strconv.Atoi(t.text)
+		if t.kind != tokenNumber || err != nil {
+			return nil, fmt.Errorf("syntax error, invalid TOP value '%s'", t.text)
+		}
+		q.top = top
+	}
+
+	switch {
+	case p.punct("*"):
+		q.star = true
+
+	case p.keyword("VALUE"):
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		q.value = value
+
+	default:
+		for {
+			expr, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+
+			name := "$" + strconv.Itoa(len(q.projections)+1)
+			if path, ok := expr.(pathExpr); ok && len(path.parts) > 0 {
+				if last, ok := path.parts[len(path.parts)-1].(literalExpr); ok {
+					if s, ok := last.value.(string); ok {
+						name = s
+					}
+				}
+			}
+
+			if p.keyword("AS") {
+				name = p.next().text
+			}
+
+			q.projections = append(q.projections, projection{name: name, expr: expr})
+			if !p.punct(",") {
+				break
+			}
+		}
+	}
+
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+
+	alias := p.next()
+	if alias.kind != tokenIdent {
+		return nil, fmt.Errorf("syntax error, invalid collection alias '%s'", alias.text)
+	}
+	q.alias = alias.text
+
+	if p.keyword("AS") {
+		q.alias = p.next().text
+	} else if t := p.peek(); t.kind == tokenIdent && !isKeyword(t.text) {
+		q.alias = p.next().text
+	}
+
+	if p.keyword("JOIN") {
+		return nil, fmt.Errorf("JOIN is not supported by the emulator")
+	}
+
+	if p.keyword("WHERE") {
+		where, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		q.where = where
+	}
+
+	if p.keyword("ORDER") {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+
+		for {
+			expr, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+
+			o := ordering{expr: expr}
+			if p.keyword("DESC") {
+				o.descending = true
+			} else {
+				p.keyword("ASC")
+			}
+
+			q.orderBy = append(q.orderBy, o)
+			if !p.punct(",") {
+				break
+			}
+		}
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("syntax error, unexpected '%s'", t.text)
+	}
+
+	return q, nil
+}
+
+func isKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "WHERE", "ORDER", "JOIN", "AS", "AND", "OR", "NOT", "IN", "BY", "ASC", "DESC":
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseExpression() (expression, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{or: true, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalExpr{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (expression, error) {
+	if p.keyword("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expression, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind == tokenPunct {
+		switch t.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return compareExpr{op: t.text, left: left, right: right}, nil
+		}
+	}
+
+	negate := false
+	if t.kind == tokenIdent && strings.EqualFold(t.text, "NOT") && strings.EqualFold(p.tokens[p.pos+1].text, "IN") {
+		p.next()
+		negate = true
+	}
+
+	if p.keyword("IN") {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+
+		values, err := p.parseList(")")
+		if err != nil {
+			return nil, err
+		}
+
+		var expr expression = inExpr{value: left, values: values}
+		if negate {
+			expr = notExpr{expr: expr}
+		}
+		return expr, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseList(closing string) ([]expression, error) {
+	var items []expression
+	if p.punct(closing) {
+		return items, nil
+	}
+
+	for {
+		item, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if p.punct(closing) {
+			return items, nil
+		}
+
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (expression, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error, invalid number '%s'", t.text)
+		}
+		return literalExpr{value: f}, nil
+
+	case tokenString:
+		return literalExpr{value: t.text}, nil
+
+	case tokenParam:
+		value, ok := p.params[t.text]
+		if !ok {
+			return nil, fmt.Errorf("the parameter '%s' is not defined", t.text)
+		}
+		return literalExpr{value: value}, nil
+
+	case tokenPunct:
+		switch t.text {
+		case "(":
+			expr, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expect(")")
+
+		case "-":
+			expr, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			if lit, ok := expr.(literalExpr); ok {
+				if f, ok := lit.value.(float64); ok {
+					return literalExpr{value: -f}, nil
+				}
+			}
+			return nil, fmt.Errorf("syntax error, unary minus requires a number")
+
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return arrayExpr{items: items}, nil
+
+		case "{":
+			obj := objectExpr{}
+			if p.punct("}") {
+				return obj, nil
+			}
+
+			for {
+				key := p.next()
+				if key.kind != tokenIdent && key.kind != tokenString {
+					return nil, fmt.Errorf("syntax error, invalid object key '%s'", key.text)
+				}
+
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+
+				value, err := p.parseExpression()
+				if err != nil {
+					return nil, err
+				}
+
+				obj.keys = append(obj.keys, key.text)
+				obj.values = append(obj.values, value)
+
+				if p.punct("}") {
+					return obj, nil
+				}
+
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return literalExpr{value: true}, nil
+		case "false":
+			return literalExpr{value: false}, nil
+		case "null":
+			return literalExpr{value: nil}, nil
+		case "undefined":
+			return literalExpr{value: undefined}, nil
+		}
+
+		if strings.EqualFold(t.text, "udf") && p.isUserDefinedFunctionCall() {
+			p.next()
+			name := p.next()
+			p.next()
+
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+
+			fn, ok := p.udfs[name.text]
+			if !ok {
+				return nil, fmt.Errorf("'udf.%s' is not a recognized user-defined function name", name.text)
+			}
+			return funcExpr{fn: fn, args: args}, nil
+		}
+
+		if p.punct("(") {
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+
+			fn, ok := functions[strings.ToUpper(t.text)]
+			if !ok {
+				return nil, fmt.Errorf("'%s' is not a recognized built-in function name", t.text)
+			}
+			return funcExpr{fn: fn, args: args}, nil
+		}
+
+		p.roots = append(p.roots, t.text)
+		path := pathExpr{}
+		for {
+			if p.punct(".") {
+				name := p.next()
+				if name.kind != tokenIdent {
+					return nil, fmt.Errorf("syntax error, invalid property name '%s'", name.text)
+				}
+				path.parts = append(path.parts, literalExpr{value: name.text})
+				continue
+			}
+
+			if p.punct("[") {
+				index, err := p.parseExpression()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect("]"); err != nil {
+					return nil, err
+				}
+				path.parts = append(path.parts, index)
+				continue
+			}
+
+			return path, nil
+		}
+	}
+
+	return nil, fmt.Errorf("syntax error, unexpected '%s'", t.text)
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(root interface{}) interface{} {
+	return e.value
+}
+
+type pathExpr struct {
+	parts []expression
+}
+
+func (e pathExpr) eval(root interface{}) interface{} {
+	value := root
+	for _, part := range e.parts {
+		switch key := part.eval(root).(type) {
+		case string:
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return undefined
+			}
+			if value, ok = obj[key]; !ok {
+				return undefined
+			}
+
+		case float64:
+			arr, ok := value.([]interface{})
+			if !ok || int(key) < 0 || int(key) >= len(arr) {
+				return undefined
+			}
+			value = arr[int(key)]
+
+		default:
+			return undefined
+		}
+	}
+
+	return value
+}
+
+type arrayExpr struct {
+	items []expression
+}
+
+func (e arrayExpr) eval(root interface{}) interface{} {
+	values := make([]interface{}, 0, len(e.items))
+	for _, item := range e.items {
+		if v := item.eval(root); v != undefined {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+type objectExpr struct {
+	keys   []string
+	values []expression
+}
+
+func (e objectExpr) eval(root interface{}) interface{} {
+	obj := make(map[string]interface{}, len(e.keys))
+	for i, key := range e.keys {
+		if v := e.values[i].eval(root); v != undefined {
+			obj[key] = v
+		}
+	}
+	return obj
+}
+
+type logicalExpr struct {
+	or    bool
+	left  expression
+	right expression
+}
+
+func (e logicalExpr) eval(root interface{}) interface{} {
+	left, right := e.left.eval(root), e.right.eval(root)
+	if e.or {
+		if left == true || right == true {
+			return true
+		}
+		if left == false && right == false {
+			return false
+		}
+		return undefined
+	}
+
+	if left == false || right == false {
+		return false
+	}
+	if left == true && right == true {
+		return true
+	}
+	return undefined
+}
+
+type notExpr struct {
+	expr expression
+}
+
+func (e notExpr) eval(root interface{}) interface{} {
+	if b, ok := e.expr.eval(root).(bool); ok {
+		return !b
+	}
+	return undefined
+}
+
+type compareExpr struct {
+	op    string
+	left  expression
+	right expression
+}
+
+func (e compareExpr) eval(root interface{}) interface{} {
+	left, right := e.left.eval(root), e.right.eval(root)
+	if left == undefined || right == undefined {
+		return undefined
+	}
+
+	switch e.op {
+	case "=":
+		return reflect.DeepEqual(left, right)
+	case "!=", "<>":
+		return !reflect.DeepEqual(left, right)
+	}
+
+	var c int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return undefined
+		}
+		c = compareFloat(l, r)
+
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return undefined
+		}
+		c = strings.Compare(l, r)
+
+	default:
+		return undefined
+	}
+
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+type inExpr struct {
+	value  expression
+	values []expression
+}
+
+func (e inExpr) eval(root interface{}) interface{} {
+	value := e.value.eval(root)
+	if value == undefined {
+		return undefined
+	}
+
+	for _, v := range e.values {
+		if reflect.DeepEqual(value, v.eval(root)) {
+			return true
+		}
+	}
+	return false
+}
+
+type funcExpr struct {
+	fn   func(args []interface{}) interface{}
+	args []expression
+}
+
+func (e funcExpr) eval(root interface{}) interface{} {
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		args[i] = arg.eval(root)
+	}
+	return e.fn(args)
+}
+
+var functions = map[string]func(args []interface{}) interface{}{
+	"IS_DEFINED": func(args []interface{}) interface{} {
+		return len(args) == 1 && args[0] != undefined
+	},
+	"IS_NULL": func(args []interface{}) interface{} {
+		return len(args) == 1 && args[0] == nil
+	},
+	"IS_BOOL":   typeCheck(func(v interface{}) bool { _, ok := v.(bool); return ok }),
+	"IS_NUMBER": typeCheck(func(v interface{}) bool { _, ok := v.(float64); return ok }),
+	"IS_STRING": typeCheck(func(v interface{}) bool { _, ok := v.(string); return ok }),
+	"IS_ARRAY":  typeCheck(func(v interface{}) bool { _, ok := v.([]interface{}); return ok }),
+	"IS_OBJECT": typeCheck(func(v interface{}) bool { _, ok := v.(map[string]interface{}); return ok }),
+	"ARRAY_CONTAINS": func(args []interface{}) interface{} {
+		if len(args) < 2 {
+			return undefined
+		}
+
+		arr, ok := args[0].([]interface{})
+		if !ok {
+			return undefined
+		}
+
+		partial := len(args) > 2 && args[2] == true
+		for _, item := range arr {
+			if reflect.DeepEqual(item, args[1]) || (partial && containsPartial(item, args[1])) {
+				return true
+			}
+		}
+		return false
+	},
+	"ARRAY_LENGTH": func(args []interface{}) interface{} {
+		if arr, ok := args[0].([]interface{}); ok && len(args) == 1 {
+			return float64(len(arr))
+		}
+		return undefined
+	},
+	"CONTAINS":   stringPredicate(strings.Contains),
+	"STARTSWITH": stringPredicate(strings.HasPrefix),
+	"ENDSWITH":   stringPredicate(strings.HasSuffix),
+	"LOWER":      stringFunc(strings.ToLower),
+	"UPPER":      stringFunc(strings.ToUpper),
+	"LENGTH": func(args []interface{}) interface{} {
+		if s, ok := args[0].(string); ok && len(args) == 1 {
+			return float64(len([]rune(s)))
+		}
+		return undefined
+	},
+}
+
+func typeCheck(check func(v interface{}) bool) func(args []interface{}) interface{} {
+	return func(args []interface{}) interface{} {
+		return len(args) == 1 && check(args[0])
+	}
+}
+
+func stringPredicate(predicate func(s, substr string) bool) func(args []interface{}) interface{} {
+	return func(args []interface{}) interface{} {
+		if len(args) != 2 {
+			return undefined
+		}
+
+		s, ok1 := args[0].(string)
+		substr, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return undefined
+		}
+		return predicate(s, substr)
+	}
+}
+
+func stringFunc(fn func(s string) string) func(args []interface{}) interface{} {
+	return func(args []interface{}) interface{} {
+		if s, ok := args[0].(string); ok && len(args) == 1 {
+			return fn(s)
+		}
+		return undefined
+	}
+}
+
+func containsPartial(item interface{}, partial interface{}) bool {
+	obj, ok1 := item.(map[string]interface{})
+	match, ok2 := partial.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	for k, v := range match {
+		if !reflect.DeepEqual(obj[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+func compareFloat(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareOrder(a interface{}, b interface{}) int {
+	if c := typeRank(a) - typeRank(b); c != 0 {
+		return c
+	}
+
+	switch av := a.(type) {
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		}
+		if !av {
+			return -1
+		}
+		return 1
+
+	case float64:
+		return compareFloat(av, b.(float64))
+
+	case string:
+		return strings.Compare(av, b.(string))
+
+	case []interface{}, map[string]interface{}:
+		ab, _ := json.Marshal(av)
+		bb, _ := json.Marshal(b)
+		return strings.Compare(string(ab), string(bb))
+	}
+
+	return 0
+}
+
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case undefinedValue:
+		return 0
+	case nil:
+		return 1
+	case bool:
+		return 2
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	}
+	return 6
+}
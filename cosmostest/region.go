@@ -0,0 +1,144 @@
+package cosmostest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const substatusWriteForbidden = "3"
+
+type Region struct {
+	*httptest.Server
+
+	Name string
+
+	server        *Server
+	writable      bool
+	mu            sync.Mutex
+	requests      int
+	failures      int
+	failureStatus int
+	substatus     int
+}
+
+func (s *Server) AddRegion(name string, writable bool) *Region {
+	r := &Region{
+		Name:     name,
+		server:   s,
+		writable: writable,
+	}
+	r.Server = httptest.NewServer(r)
+
+	s.mu.Lock()
+	s.regions = append(s.regions, r)
+	s.mu.Unlock()
+
+	return r
+}
+
+func (r *Region) SetWritable(writable bool) {
+	r.server.mu.Lock()
+	defer r.server.mu.Unlock()
+
+	r.writable = writable
+}
+
+func (r *Region) Fail(requests int, status int, substatus int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.failures = requests
+	r.failureStatus = status
+	r.substatus = substatus
+}
+
+func (r *Region) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.requests
+}
+
+func (r *Region) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	r.requests++
+	fail := r.failures > 0
+	status, substatus := r.failureStatus, r.substatus
+	if fail {
+		r.failures--
+	}
+	r.mu.Unlock()
+
+	if fail {
+		if substatus != 0 {
+			w.Header().Set(api.HEADER_SUBSTATUS, strconv.Itoa(substatus))
+		}
+		writeError(w, status, http.StatusText(status))
+		return
+	}
+
+	r.server.mu.Lock()
+	writable := r.writable
+	r.server.mu.Unlock()
+
+	if !writable && isWriteRequest(req) {
+		w.Header().Set(api.HEADER_SUBSTATUS, substatusWriteForbidden)
+		writeError(w, http.StatusForbidden, "The requested operation cannot be performed at this region.")
+		return
+	}
+
+	r.server.ServeHTTP(w, req)
+}
+
+func isWriteRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return false
+	case http.MethodPost:
+		return !strings.EqualFold(r.Header.Get(api.HEADER_IS_QUERY), "True")
+	}
+
+	return true
+}
+
+func (s *Server) accountLocations() ([]api.DatabaseAccountLocation, []api.DatabaseAccountLocation) {
+	if len(s.regions) == 0 {
+		location := api.DatabaseAccountLocation{
+			Name:                    "Local",
+			DatabaseAccountEndpoint: s.URL + "/",
+		}
+		return []api.DatabaseAccountLocation{location}, []api.DatabaseAccountLocation{location}
+	}
+
+	var writable, readable []api.DatabaseAccountLocation
+	for _, r := range s.regions {
+		location := api.DatabaseAccountLocation{
+			Name:                    r.Name,
+			DatabaseAccountEndpoint: r.URL + "/",
+		}
+
+		if r.writable {
+			writable = append(writable, location)
+		}
+		readable = append(readable, location)
+	}
+
+	return writable, readable
+}
+
+func (s *Server) Close() {
+	s.mu.Lock()
+	regions := s.regions
+	s.mu.Unlock()
+
+	for _, r := range regions {
+		r.Close()
+	}
+
+	s.Server.Close()
+}
@@ -0,0 +1,303 @@
+package cosmostest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type StoredProcedureFunc func(ctx *ScriptContext, args []json.RawMessage) (interface{}, error)
+
+type TriggerFunc func(ctx *ScriptContext, document map[string]interface{}) error
+
+type UserDefinedFunc func(args []interface{}) interface{}
+
+type ScriptContext struct {
+	server       *Server
+	coll         *collection
+	self         string
+	partitionKey []interface{}
+	pending      map[string]resource
+	order        []string
+	log          strings.Builder
+}
+
+func (s *Server) RegisterStoredProcedure(id string, fn StoredProcedureFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.storedProcedures[id] = fn
+}
+
+func (s *Server) RegisterTrigger(id string, fn TriggerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.triggers[id] = fn
+}
+
+func (s *Server) RegisterUserDefinedFunction(id string, fn UserDefinedFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.functions[id] = fn
+}
+
+func (s *Server) newScriptContext(coll *collection, segments []string, partitionKey []interface{}) *ScriptContext {
+	return &ScriptContext{
+		server:       s,
+		coll:         coll,
+		self:         strings.Join(segments[:4], "/"),
+		partitionKey: partitionKey,
+		pending:      make(map[string]resource),
+	}
+}
+
+func (c *ScriptContext) Log(format string, args ...interface{}) {
+	fmt.Fprintf(&c.log, format, args...)
+}
+
+func (c *ScriptContext) ReadDocument(id string) (map[string]interface{}, bool) {
+	key := c.coll.documentKey(c.partitionKey, id)
+	if document, ok := c.pending[key]; ok {
+		return copyResource(document), true
+	}
+
+	document, ok := c.coll.documents.get(key)
+	if !ok {
+		return nil, false
+	}
+	return copyResource(document), true
+}
+
+func (c *ScriptContext) UpsertDocument(document map[string]interface{}) error {
+	r := resource(copyResource(document))
+	if r.id() == "" {
+		return errors.New("the document is missing the required property 'id'")
+	}
+
+	if !partitionKeyEqual(c.coll.partitionKey(r), c.partitionKey) {
+		return errors.New("the document partition key does not match the partition key of the request")
+	}
+
+	key := c.coll.documentKey(c.partitionKey, r.id())
+	if _, ok := c.pending[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.pending[key] = r
+	return nil
+}
+
+func (c *ScriptContext) commit() {
+	for _, key := range c.order {
+		document := c.pending[key]
+		existing, _ := c.coll.documents.get(key)
+		c.server.stamp(document, c.self+"/docs/"+document.id()+"/", existing)
+		c.coll.documents.set(key, document)
+	}
+}
+
+func copyResource(r map[string]interface{}) map[string]interface{} {
+	var out map[string]interface{}
+	b, _ := json.Marshal(r)
+	json.Unmarshal(b, &out) // nolint:errcheck
+	return out
+}
+
+func (s *Server) userDefinedFunctions(coll *collection) map[string]UserDefinedFunc {
+	udfs := make(map[string]UserDefinedFunc)
+	for _, udf := range coll.udfs.all() {
+		if fn, ok := s.functions[udf.id()]; ok {
+			udfs[udf.id()] = fn
+		}
+	}
+	return udfs
+}
+
+func isScriptResource(resourceType string) bool {
+	return resourceType == "sprocs" || resourceType == "triggers" || resourceType == "udfs"
+}
+
+func (c *collection) scripts(resourceType string) (*resourceList, string) {
+	switch resourceType {
+	case "triggers":
+		return c.triggers, "Triggers"
+	case "udfs":
+		return c.udfs, "UserDefinedFunctions"
+	default:
+		return c.sprocs, "StoredProcedures"
+	}
+}
+
+func (s *Server) serveScripts(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	_, coll, ok := s.lookupCollection(w, segments)
+	if !ok {
+		return
+	}
+
+	scripts, listKey := coll.scripts(segments[4])
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_rid":   coll.resource["_rid"],
+			listKey:  scripts.all(),
+			"_count": len(scripts.all()),
+		})
+
+	case http.MethodPost:
+		script, ok := decodeResource(w, body)
+		if !ok {
+			return
+		}
+
+		if _, exists := scripts.get(script.id()); exists {
+			writeError(w, http.StatusConflict, "Resource with specified id or name already exists.")
+			return
+		}
+
+		s.stamp(script, strings.Join(segments, "/")+"/"+script.id()+"/", nil)
+		scripts.set(script.id(), script)
+		writeJSON(w, http.StatusCreated, script)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) serveScript(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	_, coll, ok := s.lookupCollection(w, segments)
+	if !ok {
+		return
+	}
+
+	scripts, _ := coll.scripts(segments[4])
+	existing, exists := scripts.get(segments[5])
+	if !exists {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, existing)
+
+	case http.MethodPost:
+		if segments[4] != "sprocs" {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		s.executeStoredProcedure(w, r, coll, segments, body)
+
+	case http.MethodPut:
+		script, ok := decodeResource(w, body)
+		if !ok {
+			return
+		}
+
+		if script.id() != segments[5] {
+			writeError(w, http.StatusBadRequest, "The id in the request body does not match the resource link.")
+			return
+		}
+
+		if !checkIfMatch(w, r, existing) {
+			return
+		}
+
+		s.stamp(script, existing["_self"].(string), existing)
+		scripts.set(segments[5], script)
+		writeJSON(w, http.StatusOK, script)
+
+	case http.MethodDelete:
+		scripts.remove(segments[5])
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) executeStoredProcedure(w http.ResponseWriter, r *http.Request, coll *collection, segments []string, body []byte) {
+	partitionKey, ok := requirePartitionKey(w, r, coll, nil)
+	if !ok {
+		return
+	}
+
+	fn, ok := s.storedProcedures[segments[5]]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Stored procedure %q has no registered implementation.", segments[5]))
+		return
+	}
+
+	var args []json.RawMessage
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &args); err != nil {
+			writeError(w, http.StatusBadRequest, "The stored procedure arguments must be a JSON array.")
+			return
+		}
+	}
+
+	ctx := s.newScriptContext(coll, segments, partitionKey)
+
+	result, err := fn(ctx, args)
+	if strings.EqualFold(r.Header.Get(api.HEADER_SCRIPT_LOGGING), "true") {
+		w.Header().Set(api.HEADER_SCRIPT_LOG_RESULTS, url.QueryEscape(ctx.log.String()))
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Encountered exception while executing function. Exception = "+err.Error())
+		return
+	}
+
+	ctx.commit()
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) runTriggers(w http.ResponseWriter, r *http.Request, coll *collection, segments []string, partitionKey []interface{}, triggerType api.TriggerType, operation api.TriggerOperation, document resource) (*ScriptContext, bool) {
+	header := api.HEADER_PRE_TRIGGER_INCLUDE
+	if triggerType == api.TriggerTypePost {
+		header = api.HEADER_POST_TRIGGER_INCLUDE
+	}
+
+	ctx := s.newScriptContext(coll, segments, partitionKey)
+	for _, id := range strings.Split(r.Header.Get(header), ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+
+		trigger, ok := coll.triggers.get(id)
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Trigger %q does not exist.", id))
+			return nil, false
+		}
+
+		if !strings.EqualFold(fmt.Sprint(trigger["triggerType"]), string(triggerType)) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Trigger %q is not a %s-trigger.", id, strings.ToLower(string(triggerType))))
+			return nil, false
+		}
+
+		if op := fmt.Sprint(trigger["triggerOperation"]); !strings.EqualFold(op, string(api.TriggerOperationAll)) && !strings.EqualFold(op, string(operation)) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Trigger %q cannot be used for %s operations.", id, strings.ToLower(string(operation))))
+			return nil, false
+		}
+
+		fn, ok := s.triggers[id]
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Trigger %q has no registered implementation.", id))
+			return nil, false
+		}
+
+		if err := fn(ctx, document); err != nil {
+			writeError(w, http.StatusBadRequest, "Encountered exception while executing function. Exception = "+err.Error())
+			return nil, false
+		}
+	}
+
+	return ctx, true
+}
@@ -0,0 +1,1035 @@
+package cosmostest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+)
+
+const (
+	EmulatorKey = "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+	maxClockSkew = 15 * time.Minute
+)
+
+type Option func(*Server)
+
+func WithKey(key string) Option {
+	return func(s *Server) {
+		s.key = key
+	}
+}
+
+func WithSecondaryKey(key string) Option {
+	return func(s *Server) {
+		s.secondaryKey = key
+	}
+}
+
+func WithReadOnlyKeys(keys ...string) Option {
+	return func(s *Server) {
+		s.readOnlyKeys = append(s.readOnlyKeys, keys...)
+	}
+}
+
+func WithPartitionKeyRanges(ranges int) Option {
+	return func(s *Server) {
+		s.partitionKeyRanges = ranges
+	}
+}
+
+func WithConsistencyLevel(level api.ConsistencyLevel) Option {
+	return func(s *Server) {
+		s.consistencyLevel = level
+	}
+}
+
+func WithPageSize(pageSize int) Option {
+	return func(s *Server) {
+		s.pageSize = pageSize
+	}
+}
+
+type Server struct {
+	*httptest.Server
+
+	key                string
+	secondaryKey       string
+	readOnlyKeys       []string
+	pageSize           int
+	partitionKeyRanges int
+	consistencyLevel   api.ConsistencyLevel
+	mu                 sync.Mutex
+	sequence           int64
+	throttled          int
+	failures           int
+	failureStatus      int
+	requestCharge      float64
+	regions            []*Region
+	tokens             *TokenServer
+	resourceTokens     map[string]resourceGrant
+	lastHeaders        http.Header
+	databases          *resourceList
+	children           map[string]*database
+	storedProcedures   map[string]StoredProcedureFunc
+	triggers           map[string]TriggerFunc
+	functions          map[string]UserDefinedFunc
+}
+
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		key:                EmulatorKey,
+		partitionKeyRanges: 1,
+		consistencyLevel:   api.ConsistencyLevelSession,
+		databases:          newResourceList(),
+		children:           make(map[string]*database),
+		storedProcedures:   make(map[string]StoredProcedureFunc),
+		triggers:           make(map[string]TriggerFunc),
+		functions:          make(map[string]UserDefinedFunc),
+		resourceTokens:     make(map[string]resourceGrant),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *Server) Client(opts ...cosmos.DialOption) (*cosmos.Client, error) {
+	endpoint, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []cosmos.DialOption{
+		cosmos.WithEndpoint(endpoint),
+		cosmos.WithKey(s.key),
+	}
+
+	return cosmos.Dial(append(options, opts...)...)
+}
+
+func (s *Server) SetKeys(primary string, secondary string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.key = primary
+	s.secondaryKey = secondary
+}
+
+func (s *Server) Throttle(requests int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.throttled = requests
+}
+
+func (s *Server) Fail(requests int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = requests
+	s.failureStatus = status
+}
+
+func (s *Server) SetRequestCharge(charge float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requestCharge = charge
+}
+
+func (s *Server) LastRequestHeader(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastHeaders.Get(name)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.Header().Set(api.HEADER_ACTIVITY_ID, fmt.Sprintf("%08x-0000-0000-0000-000000000000", s.sequence))
+	s.lastHeaders = r.Header.Clone()
+
+	if s.requestCharge > 0 {
+		w = &chargeWriter{ResponseWriter: w, charge: strconv.FormatFloat(s.requestCharge, 'f', -1, 64)}
+	}
+
+	if s.throttled > 0 {
+		s.throttled--
+		w.Header().Set(api.HEADER_RETRY_AFTER_MS, "1")
+		writeError(w, http.StatusTooManyRequests, "Request rate is large")
+		return
+	}
+
+	if s.failures > 0 {
+		s.failures--
+		writeError(w, s.failureStatus, http.StatusText(s.failureStatus))
+		return
+	}
+
+	if err := s.authorize(r); err != nil {
+		status := http.StatusUnauthorized
+		if _, ok := err.(forbiddenError); ok {
+			status = http.StatusForbidden
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	if !s.checkConsistencyLevel(w, r) {
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) == 1 && segments[0] == "" {
+		s.serveDatabaseAccount(w, r)
+		return
+	}
+
+	if segments[0] == "dbs" && len(segments) > 2 && segments[2] == "users" {
+		s.serveUserResource(w, r, segments, body)
+		return
+	}
+
+	if segments[0] != "dbs" || (len(segments) > 2 && segments[2] != "colls") {
+		writeError(w, http.StatusNotFound, "unsupported resource link")
+		return
+	}
+
+	switch len(segments) {
+	case 1:
+		s.serveDatabases(w, r, body)
+	case 2:
+		s.serveDatabase(w, r, segments)
+	case 3:
+		s.serveCollections(w, r, segments, body)
+	case 4:
+		s.serveCollection(w, r, segments, body)
+	default:
+		s.serveCollectionResource(w, r, segments, body)
+	}
+}
+
+func (s *Server) serveCollectionResource(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	switch {
+	case segments[4] == "docs" && len(segments) == 5:
+		s.serveDocuments(w, r, segments, body)
+	case segments[4] == "docs" && len(segments) == 6:
+		s.serveDocument(w, r, segments, body)
+	case segments[4] == "docs" && len(segments) == 7 && segments[6] == "attachments":
+		s.serveAttachments(w, r, segments, body)
+	case segments[4] == "docs" && len(segments) == 8 && segments[6] == "attachments":
+		s.serveAttachment(w, r, segments, body)
+	case isScriptResource(segments[4]) && len(segments) == 5:
+		s.serveScripts(w, r, segments, body)
+	case isScriptResource(segments[4]) && len(segments) == 6:
+		s.serveScript(w, r, segments, body)
+	case segments[4] == "pkranges" && len(segments) == 5:
+		s.servePartitionKeyRanges(w, r, segments)
+	default:
+		writeError(w, http.StatusNotFound, "unsupported resource link")
+	}
+}
+
+func (s *Server) authorize(r *http.Request) error {
+	date := r.Header.Get(api.HEADER_DATE)
+	signedAt, err := time.Parse(api.TIME_FORMAT, date)
+	if err != nil {
+		return fmt.Errorf("invalid %s header %q", api.HEADER_DATE, date)
+	}
+
+	if skew := time.Since(signedAt); skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("request date %q is outside the allowed window", date)
+	}
+
+	token, err := url.QueryUnescape(r.Header.Get(api.HEADER_AUTHORIZATION))
+	if err != nil {
+		return fmt.Errorf("malformed authorization token: %v", err)
+	}
+
+	fields := map[string]string{}
+	for _, part := range strings.Split(token, "&") {
+		if i := strings.Index(part, "="); i > 0 {
+			fields[part[:i]] = part[i+1:]
+		}
+	}
+
+	if fields["type"] == "aad" && fields["ver"] == "1.0" && s.tokens != nil {
+		if !s.tokens.valid(fields["sig"]) {
+			return errors.New("the access token is invalid or has expired")
+		}
+		return nil
+	}
+
+	if fields["type"] == "resource" && fields["ver"] == "1.0" {
+		return s.authorizeResourceToken(r, fields["sig"])
+	}
+
+	if fields["type"] != "master" || fields["ver"] != "1.0" {
+		return fmt.Errorf("unsupported authorization token type=%q ver=%q", fields["type"], fields["ver"])
+	}
+
+	resourceType, resourceLink := resourceFromPath(r.URL.Path)
+	payload := strings.ToLower(r.Method) + "\n" +
+		strings.ToLower(resourceType) + "\n" +
+		resourceLink + "\n" +
+		strings.ToLower(date) + "\n\n"
+
+	for _, key := range []string{s.key, s.secondaryKey} {
+		if ok, err := verifySignature(key, payload, fields["sig"]); err != nil || ok {
+			return err
+		}
+	}
+
+	for _, key := range s.readOnlyKeys {
+		if ok, err := verifySignature(key, payload, fields["sig"]); err != nil || ok {
+			if err == nil && isWriteRequest(r) {
+				return forbiddenError("read-only keys cannot be used for write operations")
+			}
+			return err
+		}
+	}
+
+	return errors.New("the input authorization token can't serve the request, payload: " + strconv.Quote(payload))
+}
+
+func verifySignature(key string, payload string, signature string) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return false, err
+	}
+
+	h := hmac.New(sha256.New, k)
+	h.Write([]byte(payload)) // nolint:errcheck
+	expected := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature)), nil
+}
+
+func resourceFromPath(path string) (string, string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 1 && segments[0] == "" {
+		return "", ""
+	}
+
+	if len(segments)%2 == 0 {
+		return segments[len(segments)-2], strings.Join(segments, "/")
+	}
+
+	return segments[len(segments)-1], strings.Join(segments[:len(segments)-1], "/")
+}
+
+func (s *Server) serveDatabases(w http.ResponseWriter, r *http.Request, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		databases := s.databases.all()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_rid":      "",
+			"Databases": databases,
+			"_count":    len(databases),
+		})
+
+	case http.MethodPost:
+		db, ok := decodeResource(w, body)
+		if !ok {
+			return
+		}
+
+		if _, exists := s.databases.get(db.id()); exists {
+			writeError(w, http.StatusConflict, "Resource with specified id or name already exists.")
+			return
+		}
+
+		s.stamp(db, "dbs/"+db.id()+"/", nil)
+		s.databases.set(db.id(), db)
+		s.children[db.id()] = &database{
+			resource:    db,
+			collections: make(map[string]*collection),
+			users:       newResourceList(),
+			permissions: make(map[string]*resourceList),
+		}
+		writeJSON(w, http.StatusCreated, db)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) serveDatabase(w http.ResponseWriter, r *http.Request, segments []string) {
+	db, ok := s.children[segments[1]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, db.resource)
+
+	case http.MethodDelete:
+		s.databases.remove(segments[1])
+		delete(s.children, segments[1])
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) serveCollections(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	db, ok := s.children[segments[1]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		collections := make([]resource, 0, len(db.collections))
+		for _, r := range s.collectionList(db) {
+			collections = append(collections, r.resource)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_rid":                db.resource["_rid"],
+			"DocumentCollections": collections,
+			"_count":              len(collections),
+		})
+
+	case http.MethodPost:
+		coll, ok := decodeResource(w, body)
+		if !ok {
+			return
+		}
+
+		if _, exists := db.collections[coll.id()]; exists {
+			writeError(w, http.StatusConflict, "Resource with specified id or name already exists.")
+			return
+		}
+
+		s.stamp(coll, "dbs/"+segments[1]+"/colls/"+coll.id()+"/", nil)
+		db.collections[coll.id()] = newCollection(coll, s.partitionKeyRanges)
+		writeJSON(w, http.StatusCreated, coll)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) collectionList(db *database) []*collection {
+	collections := make([]*collection, 0, len(db.collections))
+	for _, c := range db.collections {
+		collections = append(collections, c)
+	}
+
+	sort.Slice(collections, func(i, j int) bool {
+		return collections[i].resource["_rid"].(string) < collections[j].resource["_rid"].(string)
+	})
+
+	return collections
+}
+
+func (s *Server) serveCollection(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	db, coll, ok := s.lookupCollection(w, segments)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, coll.resource)
+
+	case http.MethodPut:
+		replacement, ok := decodeResource(w, body)
+		if !ok {
+			return
+		}
+
+		if replacement.id() != segments[3] {
+			writeError(w, http.StatusBadRequest, "The id in the request body does not match the resource link.")
+			return
+		}
+
+		if _, ok := replacement["partitionKey"]; !ok {
+			replacement["partitionKey"] = coll.resource["partitionKey"]
+		}
+
+		s.stamp(replacement, coll.resource["_self"].(string), coll.resource)
+		coll.resource = replacement
+		writeJSON(w, http.StatusOK, replacement)
+
+	case http.MethodDelete:
+		delete(db.collections, segments[3])
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) lookupCollection(w http.ResponseWriter, segments []string) (*database, *collection, bool) {
+	db, ok := s.children[segments[1]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return nil, nil, false
+	}
+
+	coll, ok := db.collections[segments[3]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return nil, nil, false
+	}
+
+	return db, coll, true
+}
+
+func (s *Server) serveDocuments(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	_, coll, ok := s.lookupCollection(w, segments)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if r.Header.Get(api.HEADER_A_IM) == api.INCREMENTAL_FEED {
+			s.changeFeed(w, r, coll)
+			return
+		}
+
+		s.writeDocuments(w, r, coll, coll.documents.all())
+
+	case http.MethodPost:
+		if strings.EqualFold(r.Header.Get(api.HEADER_IS_BATCH_REQUEST), "True") {
+			s.executeBatch(w, r, coll, segments, body)
+			return
+		}
+
+		if strings.EqualFold(r.Header.Get(api.HEADER_IS_QUERY), "True") {
+			s.queryDocuments(w, r, coll, body)
+			return
+		}
+
+		document, ok := decodeResource(w, body)
+		if !ok {
+			return
+		}
+
+		partitionKey, ok := requirePartitionKey(w, r, coll, nil)
+		if !ok {
+			return
+		}
+
+		pre, ok := s.runTriggers(w, r, coll, segments, partitionKey, api.TriggerTypePre, api.TriggerOperationCreate, document)
+		if !ok {
+			return
+		}
+
+		if !partitionKeyEqual(coll.partitionKey(document), partitionKey) {
+			writeError(w, http.StatusBadRequest, "PartitionKey extracted from document doesn't match the one specified in the header")
+			return
+		}
+
+		key := coll.documentKey(partitionKey, document.id())
+		existing, exists := coll.documents.get(key)
+		status := http.StatusCreated
+		if exists {
+			if !strings.EqualFold(r.Header.Get(api.HEADER_IS_UPSERT), "True") {
+				writeError(w, http.StatusConflict, "Entity with the specified id already exists in the system.")
+				return
+			}
+
+			if !checkIfMatch(w, r, existing) {
+				return
+			}
+			status = http.StatusOK
+		}
+
+		s.stamp(document, strings.Join(segments, "/")+"/"+document.id()+"/", existing)
+		post, ok := s.runTriggers(w, r, coll, segments, partitionKey, api.TriggerTypePost, api.TriggerOperationCreate, copyResource(document))
+		if !ok {
+			return
+		}
+
+		pre.commit()
+		coll.documents.set(key, document)
+		post.commit()
+		writeDocument(w, r, coll, status, document)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) queryDocuments(w http.ResponseWriter, r *http.Request, coll *collection, body []byte) {
+	var q api.Query
+	if err := json.Unmarshal(body, &q); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	documents := coll.documents.all()
+	if header := r.Header.Get(api.HEADER_PARTITION_KEY); header != "" {
+		partitionKey, err := parsePartitionKeyHeader(header)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		filtered := make([]resource, 0, len(documents))
+		for _, d := range documents {
+			if partitionKeyEqual(coll.partitionKey(d), partitionKey) {
+				filtered = append(filtered, d)
+			}
+		}
+		documents = filtered
+	} else if !strings.EqualFold(r.Header.Get(api.HEADER_QUERY_CROSSPARTITION), "True") {
+		writeError(w, http.StatusBadRequest, "Cross partition query is required but disabled. Please set x-ms-documentdb-query-enablecrosspartition to true.")
+		return
+	}
+
+	results, err := executeQuery(q, documents, s.userDefinedFunctions(coll))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.writeDocuments(w, r, coll, results)
+}
+
+func (s *Server) writeDocuments(w http.ResponseWriter, r *http.Request, coll *collection, documents interface{}) {
+	items := toSlice(documents)
+
+	offset := 0
+	if continuation := r.Header.Get(api.HEADER_CONTINUATION); continuation != "" {
+		var err error
+		if offset, err = strconv.Atoi(continuation); err != nil || offset < 0 || offset > len(items) {
+			writeError(w, http.StatusBadRequest, "invalid continuation token")
+			return
+		}
+	}
+
+	limit := s.pageSize
+	if maxItemCount, err := strconv.Atoi(r.Header.Get(api.HEADER_MAX_ITEM_COUNT)); err == nil && maxItemCount > 0 {
+		if limit <= 0 || maxItemCount < limit {
+			limit = maxItemCount
+		}
+	}
+
+	page := items[offset:]
+	if limit > 0 && len(page) > limit {
+		page = page[:limit]
+		w.Header().Set(api.HEADER_CONTINUATION, strconv.Itoa(offset+limit))
+	}
+
+	w.Header().Set(api.HEADER_ITEM_COUNT, strconv.Itoa(len(page)))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_rid":      coll.resource["_rid"],
+		"Documents": page,
+		"_count":    len(page),
+	})
+}
+
+func toSlice(documents interface{}) []interface{} {
+	switch v := documents.(type) {
+	case []interface{}:
+		return v
+
+	case []resource:
+		items := make([]interface{}, len(v))
+		for i, d := range v {
+			items[i] = d
+		}
+		return items
+	}
+
+	return nil
+}
+
+func (s *Server) serveDocument(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	_, coll, ok := s.lookupCollection(w, segments)
+	if !ok {
+		return
+	}
+
+	partitionKey, ok := requirePartitionKey(w, r, coll, nil)
+	if !ok {
+		return
+	}
+
+	key := coll.documentKey(partitionKey, segments[5])
+	existing, exists := coll.documents.get(key)
+	if !exists {
+		writeError(w, http.StatusNotFound, "Entity with the specified id does not exist in the system.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if ifNoneMatch := r.Header.Get(api.HEADER_IF_NONE_MATCH); ifNoneMatch != "" && ifNoneMatch == existing.etag() {
+			w.Header().Set(api.HEADER_ETAG, existing.etag())
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		writeDocument(w, r, coll, http.StatusOK, existing)
+
+	case http.MethodPut:
+		document, ok := decodeResource(w, body)
+		if !ok {
+			return
+		}
+
+		if !checkIfMatch(w, r, existing) {
+			return
+		}
+
+		pre, ok := s.runTriggers(w, r, coll, segments, partitionKey, api.TriggerTypePre, api.TriggerOperationReplace, document)
+		if !ok {
+			return
+		}
+
+		if document.id() != segments[5] {
+			writeError(w, http.StatusBadRequest, "The id in the request body does not match the resource link.")
+			return
+		}
+
+		if !partitionKeyEqual(coll.partitionKey(document), partitionKey) {
+			writeError(w, http.StatusBadRequest, "PartitionKey extracted from document doesn't match the one specified in the header")
+			return
+		}
+
+		s.stamp(document, existing["_self"].(string), existing)
+		post, ok := s.runTriggers(w, r, coll, segments, partitionKey, api.TriggerTypePost, api.TriggerOperationReplace, copyResource(document))
+		if !ok {
+			return
+		}
+
+		pre.commit()
+		coll.documents.set(key, document)
+		post.commit()
+		writeDocument(w, r, coll, http.StatusOK, document)
+
+	case http.MethodPatch:
+		var req api.PatchDocumentRequest
+		if err := json.Unmarshal(body, &req); err != nil || len(req.Operations) == 0 {
+			writeError(w, http.StatusBadRequest, "The patch request is invalid.")
+			return
+		}
+
+		if !checkIfMatch(w, r, existing) {
+			return
+		}
+
+		if req.Condition != "" {
+			matches, err := executeQuery(api.Query{Query: "SELECT * " + req.Condition}, []resource{existing}, s.userDefinedFunctions(coll))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			if len(matches) == 0 {
+				writeError(w, http.StatusPreconditionFailed, "One of the specified pre-condition is not met")
+				return
+			}
+		}
+
+		document, err := applyPatch(existing, req.Operations)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if document.id() != segments[5] || !partitionKeyEqual(coll.partitionKey(document), partitionKey) {
+			writeError(w, http.StatusBadRequest, "Patch operations cannot modify the id or partition key.")
+			return
+		}
+
+		s.stamp(document, existing["_self"].(string), existing)
+		coll.documents.set(key, document)
+		writeDocument(w, r, coll, http.StatusOK, document)
+
+	case http.MethodDelete:
+		if !checkIfMatch(w, r, existing) {
+			return
+		}
+
+		pre, ok := s.runTriggers(w, r, coll, segments, partitionKey, api.TriggerTypePre, api.TriggerOperationDelete, copyResource(existing))
+		if !ok {
+			return
+		}
+
+		post, ok := s.runTriggers(w, r, coll, segments, partitionKey, api.TriggerTypePost, api.TriggerOperationDelete, copyResource(existing))
+		if !ok {
+			return
+		}
+
+		pre.commit()
+		coll.documents.remove(key)
+		delete(coll.attachments, key)
+		post.commit()
+		s.sequence++
+		setSessionToken(w, coll, existing, s.sequence)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) serveAttachments(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	_, coll, ok := s.lookupCollection(w, segments)
+	if !ok {
+		return
+	}
+
+	document, attachments, ok := lookupAttachments(w, r, coll, segments)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_rid":        document["_rid"],
+			"Attachments": attachments.all(),
+			"_count":      len(attachments.all()),
+		})
+
+	case http.MethodPost:
+		attachment, ok := s.decodeAttachment(w, r, body)
+		if !ok {
+			return
+		}
+
+		if _, exists := attachments.get(attachment.id()); exists {
+			writeError(w, http.StatusConflict, "Resource with specified id or name already exists.")
+			return
+		}
+
+		s.stamp(attachment, strings.Join(segments, "/")+"/"+attachment.id()+"/", nil)
+		attachments.set(attachment.id(), attachment)
+		writeJSON(w, http.StatusCreated, attachment)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) serveAttachment(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	_, coll, ok := s.lookupCollection(w, segments)
+	if !ok {
+		return
+	}
+
+	_, attachments, ok := lookupAttachments(w, r, coll, segments)
+	if !ok {
+		return
+	}
+
+	existing, exists := attachments.get(segments[7])
+	if !exists {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, existing)
+
+	case http.MethodPut:
+		attachment, ok := s.decodeAttachment(w, r, body)
+		if !ok {
+			return
+		}
+
+		if !checkIfMatch(w, r, existing) {
+			return
+		}
+
+		attachment["id"] = segments[7]
+		s.stamp(attachment, existing["_self"].(string), existing)
+		attachments.set(segments[7], attachment)
+		writeJSON(w, http.StatusOK, attachment)
+
+	case http.MethodDelete:
+		attachments.remove(segments[7])
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func lookupAttachments(w http.ResponseWriter, r *http.Request, coll *collection, segments []string) (resource, *resourceList, bool) {
+	partitionKey, ok := requirePartitionKey(w, r, coll, nil)
+	if !ok {
+		return nil, nil, false
+	}
+
+	key := coll.documentKey(partitionKey, segments[5])
+	document, exists := coll.documents.get(key)
+	if !exists {
+		writeError(w, http.StatusNotFound, "Entity with the specified id does not exist in the system.")
+		return nil, nil, false
+	}
+
+	return document, coll.attachmentList(key), true
+}
+
+func (s *Server) decodeAttachment(w http.ResponseWriter, r *http.Request, body []byte) (resource, bool) {
+	contentType := r.Header.Get(api.HEADER_CONTENT_TYPE)
+	if strings.HasPrefix(contentType, "application/json") {
+		return decodeResource(w, body)
+	}
+
+	id := r.Header.Get("Slug")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "The Slug header is required for raw attachment content.")
+		return nil, false
+	}
+
+	return resource{
+		"id":          id,
+		"contentType": contentType,
+		"media":       fmt.Sprintf("/media/%08x", s.sequence+1),
+		"_size":       len(body),
+	}, true
+}
+
+func requirePartitionKey(w http.ResponseWriter, r *http.Request, coll *collection, document resource) ([]interface{}, bool) {
+	header := r.Header.Get(api.HEADER_PARTITION_KEY)
+	if header == "" {
+		writeError(w, http.StatusBadRequest, "PartitionKey value must be supplied for this operation.")
+		return nil, false
+	}
+
+	partitionKey, err := parsePartitionKeyHeader(header)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	if len(partitionKey) != len(coll.paths) {
+		writeError(w, http.StatusBadRequest, "Partition key provided either doesn't correspond to definition in the collection or doesn't match partition key field values specified in the document.")
+		return nil, false
+	}
+
+	if document != nil && !partitionKeyEqual(coll.partitionKey(document), partitionKey) {
+		writeError(w, http.StatusBadRequest, "PartitionKey extracted from document doesn't match the one specified in the header")
+		return nil, false
+	}
+
+	return partitionKey, true
+}
+
+func checkIfMatch(w http.ResponseWriter, r *http.Request, existing resource) bool {
+	ifMatch := r.Header.Get(api.HEADER_IF_MATCH)
+	if ifMatch == "" || ifMatch == "*" || ifMatch == existing.etag() {
+		return true
+	}
+
+	writeError(w, http.StatusPreconditionFailed, "One of the specified pre-condition is not met")
+	return false
+}
+
+func decodeResource(w http.ResponseWriter, body []byte) (resource, bool) {
+	var r resource
+	if err := json.Unmarshal(body, &r); err != nil || r == nil {
+		writeError(w, http.StatusBadRequest, "The request payload is invalid.")
+		return nil, false
+	}
+
+	if r.id() == "" {
+		writeError(w, http.StatusBadRequest, "The input content is invalid because the required properties - 'id; ' - are missing")
+		return nil, false
+	}
+
+	return r, true
+}
+
+func writeDocument(w http.ResponseWriter, r *http.Request, coll *collection, status int, document resource) {
+	setSessionToken(w, coll, document, lsn(document))
+	w.Header().Set(api.HEADER_ETAG, document.etag())
+
+	if r.Method != http.MethodGet && strings.EqualFold(r.Header.Get(api.HEADER_PREFER), "return=minimal") {
+		w.Header().Set(api.HEADER_REQUEST_CHARGE, "1")
+		w.Header().Set(api.HEADER_CONTENT_LENGTH, "0")
+		w.WriteHeader(status)
+		return
+	}
+
+	writeJSON(w, status, document)
+}
+
+func setSessionToken(w http.ResponseWriter, coll *collection, document resource, lsn int64) {
+	if kr := coll.rangeOf(document); kr != nil {
+		w.Header().Set(api.HEADER_SESSION_TOKEN, fmt.Sprintf("%s:-1#%d", kr.id, lsn))
+	}
+}
+
+type chargeWriter struct {
+	http.ResponseWriter
+
+	charge string
+}
+
+func (w *chargeWriter) WriteHeader(status int) {
+	w.Header().Set(api.HEADER_REQUEST_CHARGE, w.charge)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set(api.HEADER_CONTENT_TYPE, "application/json")
+	w.Header().Set(api.HEADER_REQUEST_CHARGE, "1")
+	w.Header().Set(api.HEADER_CONTENT_LENGTH, strconv.Itoa(len(b)))
+	w.WriteHeader(status)
+	w.Write(b) // nolint:errcheck
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	code := strings.ReplaceAll(http.StatusText(status), " ", "")
+	b, _ := json.Marshal(map[string]string{
+		"code":    code,
+		"message": message,
+	})
+
+	w.Header().Set(api.HEADER_CONTENT_TYPE, "application/json")
+	w.Header().Set(api.HEADER_CONTENT_LENGTH, strconv.Itoa(len(b)))
+	w.WriteHeader(status)
+	w.Write(b) // nolint:errcheck
+}
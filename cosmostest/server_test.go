@@ -0,0 +1,173 @@
+package cosmostest_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+	"github.com/zhevron/cosmos/query"
+)
+
+type order struct {
+	ID       string `json:"id"`
+	Etag     string `json:"_etag,omitempty"`
+	Customer string `json:"customer"`
+	Total    int    `json:"total"`
+}
+
+func newCollection(t *testing.T, opts ...cosmostest.Option) (*cosmostest.Server, *cosmos.Collection) {
+	t.Helper()
+
+	server := cosmostest.NewServer(opts...)
+	t.Cleanup(server.Close)
+
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := client.CreateDatabase(ctx, "shop")
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	coll, err := db.CreateCollection(ctx, "orders", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/customer"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	return server, coll
+}
+
+func TestDocumentLifecycle(t *testing.T) {
+	_, coll := newCollection(t)
+	ctx := context.Background()
+
+	doc := order{ID: "1", Customer: "alice", Total: 10}
+	if err := coll.CreateDocument(ctx, doc.Customer, doc, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	if err := coll.CreateDocument(ctx, doc.Customer, doc, false); !cosmos.IsConflict(err) {
+		t.Errorf("expected conflict, got %v", err)
+	}
+
+	if err := coll.CreateDocument(ctx, "bob", doc, false); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected bad request for mismatched partition key, got %v", err)
+	}
+
+	doc.Total = 20
+	if err := coll.ReplaceDocument(ctx, doc.Customer, doc); err != nil {
+		t.Fatalf("failed to replace document: %v", err)
+	}
+
+	var got order
+	if err := coll.GetDocument(ctx, doc.Customer, doc.ID, &got); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+
+	if got.Total != 20 || got.Etag == "" {
+		t.Errorf("unexpected document: %+v", got)
+	}
+
+	if err := coll.DeleteDocument(ctx, doc.Customer, doc); err != nil {
+		t.Fatalf("failed to delete document: %v", err)
+	}
+
+	if err := coll.GetDocument(ctx, doc.Customer, doc.ID, &got); !cosmos.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestQueryDocumentsPaging(t *testing.T) {
+	_, coll := newCollection(t, cosmostest.WithPageSize(2))
+	ctx := context.Background()
+
+	for i, customer := range []string{"alice", "bob", "alice", "carol", "alice"} {
+		doc := order{ID: string(rune('a' + i)), Customer: customer, Total: i}
+		if err := coll.CreateDocument(ctx, doc.Customer, doc, false); err != nil {
+			t.Fatalf("failed to create document: %v", err)
+		}
+	}
+
+	q := query.Select().Where(query.GreaterOrEqual("c.total", "@min")).OrderBy("c.total", query.Descending)
+	it, err := coll.QueryDocuments(ctx, nil, q.String(), cosmos.QueryParameter{Name: "@min", Value: 1})
+	if err != nil {
+		t.Fatalf("failed to query documents: %v", err)
+	}
+
+	var orders []order
+	if err := it.All(&orders); err != nil {
+		t.Fatalf("failed to read query results: %v", err)
+	}
+
+	if len(orders) != 4 || orders[0].Total != 4 || orders[3].Total != 1 {
+		t.Errorf("unexpected query results: %+v", orders)
+	}
+
+	it, err = coll.QueryDocuments(ctx, "alice", "SELECT * FROM c")
+	if err != nil {
+		t.Fatalf("failed to query partition: %v", err)
+	}
+
+	count := 0
+	var o order
+	for it.Next(&o) {
+		count++
+	}
+
+	if it.Err() != nil || count != 3 {
+		t.Errorf("expected 3 documents in partition, got %d (err=%v)", count, it.Err())
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	_, coll := newCollection(t)
+	ctx := context.Background()
+
+	doc := order{ID: "1", Customer: "alice"}
+	if err := coll.CreateDocument(ctx, doc.Customer, doc, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	if _, err := coll.CreateAttachmentFromReader(ctx, doc.Customer, doc, "invoice", "application/pdf", bytes.NewBufferString("%PDF")); err != nil {
+		t.Fatalf("failed to create attachment: %v", err)
+	}
+
+	attachments, err := coll.ListAttachments(ctx, doc.Customer, doc)
+	if err != nil {
+		t.Fatalf("failed to list attachments: %v", err)
+	}
+
+	if len(attachments) != 1 || attachments[0].ContentType != "application/pdf" {
+		t.Errorf("unexpected attachments: %+v", attachments)
+	}
+}
+
+func TestThrottling(t *testing.T) {
+	server, coll := newCollection(t)
+	ctx := context.Background()
+
+	server.Throttle(2)
+	if err := coll.CreateDocument(ctx, "alice", order{ID: "1", Customer: "alice"}, false); err != nil {
+		t.Errorf("expected throttled request to be retried, got %v", err)
+	}
+}
+
+func TestSignatureVerification(t *testing.T) {
+	server := cosmostest.NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(cosmos.WithKey("dGhpcyBpcyBub3QgdGhlIGtleQ=="))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if _, err := client.ListDatabases(context.Background()); !cosmos.InUnauthorized(err) {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+}
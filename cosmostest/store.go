@@ -0,0 +1,198 @@
+package cosmostest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type resource map[string]interface{}
+
+func (r resource) id() string {
+	id, _ := r["id"].(string)
+	return id
+}
+
+func (r resource) etag() string {
+	etag, _ := r["_etag"].(string)
+	return etag
+}
+
+type resourceList struct {
+	keys  []string
+	items map[string]resource
+}
+
+func newResourceList() *resourceList {
+	return &resourceList{
+		items: make(map[string]resource),
+	}
+}
+
+func (l *resourceList) get(key string) (resource, bool) {
+	r, ok := l.items[key]
+	return r, ok
+}
+
+func (l *resourceList) set(key string, r resource) {
+	if _, ok := l.items[key]; !ok {
+		l.keys = append(l.keys, key)
+	}
+	l.items[key] = r
+}
+
+func (l *resourceList) remove(key string) bool {
+	if _, ok := l.items[key]; !ok {
+		return false
+	}
+
+	delete(l.items, key)
+	for i, k := range l.keys {
+		if k == key {
+			l.keys = append(l.keys[:i], l.keys[i+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+func (l *resourceList) all() []resource {
+	resources := make([]resource, len(l.keys))
+	for i, k := range l.keys {
+		resources[i] = l.items[k]
+	}
+	return resources
+}
+
+type database struct {
+	resource    resource
+	collections map[string]*collection
+	users       *resourceList
+	permissions map[string]*resourceList
+}
+
+type collection struct {
+	resource    resource
+	paths       []string
+	ranges      []*keyRange
+	nextRangeID int
+	documents   *resourceList
+	attachments map[string]*resourceList
+	sprocs      *resourceList
+	triggers    *resourceList
+	udfs        *resourceList
+}
+
+func newCollection(r resource, ranges int) *collection {
+	paths := []string{"/id"}
+	if pk, ok := r["partitionKey"].(map[string]interface{}); ok {
+		if p, ok := pk["paths"].([]interface{}); ok && len(p) > 0 {
+			paths = make([]string, len(p))
+			for i, v := range p {
+				paths[i], _ = v.(string)
+			}
+		}
+	}
+
+	c := &collection{
+		resource:    r,
+		paths:       paths,
+		documents:   newResourceList(),
+		attachments: make(map[string]*resourceList),
+		sprocs:      newResourceList(),
+		triggers:    newResourceList(),
+		udfs:        newResourceList(),
+	}
+
+	if ranges < 1 {
+		ranges = 1
+	}
+
+	for i := 0; i < ranges; i++ {
+		c.ranges = append(c.ranges, &keyRange{
+			id:  strconv.Itoa(i),
+			min: i * rangeBuckets / ranges,
+			max: (i + 1) * rangeBuckets / ranges,
+		})
+	}
+	c.nextRangeID = ranges
+
+	return c
+}
+
+func (c *collection) partitionKey(document resource) []interface{} {
+	values := make([]interface{}, len(c.paths))
+	for i, path := range c.paths {
+		var value interface{} = map[string]interface{}(document)
+		for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				value = map[string]interface{}{}
+				break
+			}
+
+			if value, ok = m[part]; !ok {
+				value = map[string]interface{}{}
+				break
+			}
+		}
+		values[i] = value
+	}
+
+	return values
+}
+
+func (c *collection) documentKey(partitionKey []interface{}, id string) string {
+	b, _ := json.Marshal(partitionKey)
+	return string(b) + "/" + id
+}
+
+func (c *collection) attachmentList(documentKey string) *resourceList {
+	list, ok := c.attachments[documentKey]
+	if !ok {
+		list = newResourceList()
+		c.attachments[documentKey] = list
+	}
+	return list
+}
+
+func parsePartitionKeyHeader(value string) ([]interface{}, error) {
+	var partitionKey []interface{}
+	if err := json.Unmarshal([]byte(value), &partitionKey); err != nil {
+		return nil, fmt.Errorf("invalid partition key header %q: %v", value, err)
+	}
+	return partitionKey, nil
+}
+
+func partitionKeyEqual(a []interface{}, b []interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(value interface{}) interface{} {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return value
+	}
+	return out
+}
+
+func (s *Server) stamp(r resource, self string, existing resource) {
+	s.sequence++
+	r["_rid"] = fmt.Sprintf("%08x", s.sequence)
+	if existing != nil {
+		r["_rid"] = existing["_rid"]
+	}
+	r["_self"] = self
+	r["_etag"] = fmt.Sprintf("\"%08x-0000-0000-0000-%012x\"", s.sequence, time.Now().UnixNano()&0xffffffffffff)
+	r["_ts"] = time.Now().Unix()
+	r["_lsn"] = s.sequence
+}
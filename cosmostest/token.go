@@ -0,0 +1,121 @@
+package cosmostest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+const tokenPath = "/oauth2/v2.0/token"
+
+type TokenServer struct {
+	*httptest.Server
+
+	clientID     string
+	clientSecret string
+	lifetime     time.Duration
+	mu           sync.Mutex
+	issued       int
+	tokens       map[string]time.Time
+}
+
+func NewTokenServer(clientID string, clientSecret string, lifetime time.Duration) *TokenServer {
+	t := &TokenServer{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		lifetime:     lifetime,
+		tokens:       make(map[string]time.Time),
+	}
+	t.Server = httptest.NewServer(t)
+
+	return t
+}
+
+func WithTokenServer(tokens *TokenServer) Option {
+	return func(s *Server) {
+		s.tokens = tokens
+	}
+}
+
+func (t *TokenServer) TokenURL() string {
+	return t.URL + tokenPath
+}
+
+func (t *TokenServer) Issued() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.issued
+}
+
+func (t *TokenServer) Revoke() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tokens = make(map[string]time.Time)
+}
+
+func (t *TokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != tokenPath || r.Method != http.MethodPost {
+		writeTokenError(w, http.StatusNotFound, "invalid_request", "unknown token endpoint")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type", "unsupported grant type "+grantType)
+		return
+	}
+
+	if r.PostForm.Get("client_id") != t.clientID || r.PostForm.Get("client_secret") != t.clientSecret {
+		writeTokenError(w, http.StatusUnauthorized, "invalid_client", "invalid client credentials")
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		writeTokenError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	token := hex.EncodeToString(b)
+
+	t.mu.Lock()
+	t.issued++
+	t.tokens[token] = time.Now().Add(t.lifetime)
+	t.mu.Unlock()
+
+	writeTokenResponse(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(t.lifetime / time.Second),
+	})
+}
+
+func (t *TokenServer) valid(token string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	expiresOn, ok := t.tokens[token]
+	return ok && time.Now().Before(expiresOn)
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code string, description string) {
+	writeTokenResponse(w, status, map[string]interface{}{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeTokenResponse(w http.ResponseWriter, status int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) // nolint:errcheck
+}
@@ -0,0 +1,279 @@
+package cosmostest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const defaultResourceTokenExpiry = time.Hour
+
+type resourceGrant struct {
+	database   string
+	user       string
+	permission string
+	expiresOn  time.Time
+}
+
+type forbiddenError string
+
+func (e forbiddenError) Error() string {
+	return string(e)
+}
+
+func (s *Server) serveUserResource(w http.ResponseWriter, r *http.Request, segments []string, body []byte) {
+	db, ok := s.children[segments[1]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	switch {
+	case len(segments) == 3:
+		s.serveUsers(w, r, db, segments, body)
+	case len(segments) == 4:
+		s.serveUser(w, r, db, segments)
+	case len(segments) == 5 && segments[4] == "permissions":
+		s.servePermissions(w, r, db, segments, body)
+	case len(segments) == 6 && segments[4] == "permissions":
+		s.servePermission(w, r, db, segments, body)
+	default:
+		writeError(w, http.StatusNotFound, "unsupported resource link")
+	}
+}
+
+func (s *Server) serveUsers(w http.ResponseWriter, r *http.Request, db *database, segments []string, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		users := db.users.all()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_rid":   db.resource["_rid"],
+			"Users":  users,
+			"_count": len(users),
+		})
+
+	case http.MethodPost:
+		user, ok := decodeResource(w, body)
+		if !ok {
+			return
+		}
+
+		if _, exists := db.users.get(user.id()); exists {
+			writeError(w, http.StatusConflict, "Resource with specified id or name already exists.")
+			return
+		}
+
+		self := strings.Join(segments, "/") + "/" + user.id() + "/"
+		s.stamp(user, self, nil)
+		user["_permissions"] = "permissions/"
+		db.users.set(user.id(), user)
+		db.permissions[user.id()] = newResourceList()
+		writeJSON(w, http.StatusCreated, user)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) serveUser(w http.ResponseWriter, r *http.Request, db *database, segments []string) {
+	user, ok := db.users.get(segments[3])
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, user)
+
+	case http.MethodDelete:
+		db.users.remove(segments[3])
+		delete(db.permissions, segments[3])
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) servePermissions(w http.ResponseWriter, r *http.Request, db *database, segments []string, body []byte) {
+	permissions, ok := db.permissions[segments[3]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		all := permissions.all()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_rid":        db.resource["_rid"],
+			"Permissions": all,
+			"_count":      len(all),
+		})
+
+	case http.MethodPost:
+		permission, ok := decodePermission(w, body)
+		if !ok {
+			return
+		}
+
+		if _, exists := permissions.get(permission.id()); exists {
+			writeError(w, http.StatusConflict, "Resource with specified id or name already exists.")
+			return
+		}
+
+		s.stamp(permission, strings.Join(segments, "/")+"/"+permission.id()+"/", nil)
+		if !s.grantResourceToken(w, r, segments[1], segments[3], permission) {
+			return
+		}
+		permissions.set(permission.id(), permission)
+		writeJSON(w, http.StatusCreated, permission)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) servePermission(w http.ResponseWriter, r *http.Request, db *database, segments []string, body []byte) {
+	permissions, ok := db.permissions[segments[3]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	existing, ok := permissions.get(segments[5])
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if r.Header.Get(api.HEADER_EXPIRY_SECONDS) != "" && !s.grantResourceToken(w, r, segments[1], segments[3], existing) {
+			return
+		}
+		writeJSON(w, http.StatusOK, existing)
+
+	case http.MethodPut:
+		permission, ok := decodePermission(w, body)
+		if !ok {
+			return
+		}
+
+		if permission.id() != segments[5] {
+			writeError(w, http.StatusBadRequest, "The id in the request body does not match the resource link.")
+			return
+		}
+
+		if !checkIfMatch(w, r, existing) {
+			return
+		}
+
+		s.stamp(permission, existing["_self"].(string), existing)
+		if !s.grantResourceToken(w, r, segments[1], segments[3], permission) {
+			return
+		}
+		permissions.set(segments[5], permission)
+		writeJSON(w, http.StatusOK, permission)
+
+	case http.MethodDelete:
+		permissions.remove(segments[5])
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func decodePermission(w http.ResponseWriter, body []byte) (resource, bool) {
+	permission, ok := decodeResource(w, body)
+	if !ok {
+		return nil, false
+	}
+
+	switch mode, _ := permission["permissionMode"].(string); api.PermissionMode(mode) {
+	case api.PermissionModeRead, api.PermissionModeAll:
+	default:
+		writeError(w, http.StatusBadRequest, "invalid permission mode "+strconv.Quote(mode))
+		return nil, false
+	}
+
+	if link, _ := permission["resource"].(string); strings.Trim(link, "/") == "" {
+		writeError(w, http.StatusBadRequest, "the permission is missing the required property 'resource'")
+		return nil, false
+	}
+
+	return permission, true
+}
+
+func (s *Server) grantResourceToken(w http.ResponseWriter, r *http.Request, databaseID string, userID string, permission resource) bool {
+	expiry := defaultResourceTokenExpiry
+	if header := r.Header.Get(api.HEADER_EXPIRY_SECONDS); header != "" {
+		seconds, err := strconv.Atoi(header)
+		if err != nil || seconds <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid "+api.HEADER_EXPIRY_SECONDS+" header "+strconv.Quote(header))
+			return false
+		}
+		expiry = time.Duration(seconds) * time.Second
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	sig := hex.EncodeToString(b)
+
+	s.resourceTokens[sig] = resourceGrant{
+		database:   databaseID,
+		user:       userID,
+		permission: permission.id(),
+		expiresOn:  time.Now().Add(expiry),
+	}
+	permission["_token"] = "type=resource&ver=1.0&sig=" + sig
+	return true
+}
+
+func (s *Server) authorizeResourceToken(r *http.Request, sig string) error {
+	grant, ok := s.resourceTokens[sig]
+	if !ok || time.Now().After(grant.expiresOn) {
+		return errors.New("the resource token is invalid or has expired")
+	}
+
+	var permission resource
+	if db, ok := s.children[grant.database]; ok {
+		if permissions, ok := db.permissions[grant.user]; ok {
+			permission, _ = permissions.get(grant.permission)
+		}
+	}
+	if permission == nil {
+		return errors.New("the resource token has been revoked")
+	}
+
+	resourceLink, _ := permission["resource"].(string)
+	resourceLink = strings.Trim(resourceLink, "/")
+	link := strings.Trim(r.URL.Path, "/")
+	if link != resourceLink && !strings.HasPrefix(link, resourceLink+"/") {
+		return forbiddenError("the resource token does not grant access to " + link)
+	}
+
+	if mode, _ := permission["permissionMode"].(string); api.PermissionMode(mode) == api.PermissionModeRead && isWriteRequest(r) {
+		return forbiddenError("the resource token only grants read access to " + resourceLink)
+	}
+
+	if partitionKey, ok := permission["resourcePartitionKey"].([]interface{}); ok && len(partitionKey) > 0 && link != resourceLink {
+		requested, err := parsePartitionKeyHeader(r.Header.Get(api.HEADER_PARTITION_KEY))
+		if err != nil || !partitionKeyEqual(requested, partitionKey) {
+			return forbiddenError("the resource token does not grant access to the requested partition key")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const (
+	aadTokenPrefix      = "type=aad&ver=1.0&sig="
+	tokenRefreshWindow  = 5 * time.Minute
+	tokenRefreshTimeout = 30 * time.Second
+)
+
+type AccessToken struct {
+	Token     string
+	ExpiresOn time.Time
+}
+
+type TokenCredential interface {
+	GetToken(ctx context.Context, scopes []string) (AccessToken, error)
+}
+
+type tokenCache struct {
+	credential TokenCredential
+	mu         sync.Mutex
+	fetchMu    sync.Mutex
+	token      AccessToken
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func newTokenCache(credential TokenCredential) *tokenCache {
+	return &tokenCache{
+		credential: credential,
+	}
+}
+
+func (t *tokenCache) get(ctx context.Context, scopes []string) (string, error) {
+	t.mu.Lock()
+	token, fetchedAt := t.token, t.fetchedAt
+	now := time.Now()
+
+	if token.Token != "" && now.Before(token.ExpiresOn) {
+		if now.After(refreshAt(token, fetchedAt)) && !t.refreshing {
+			t.refreshing = true
+			go t.refresh(scopes)
+		}
+		t.mu.Unlock()
+		return token.Token, nil
+	}
+	t.mu.Unlock()
+
+	token, err := t.fetch(ctx, scopes, false)
+	if err != nil {
+		return "", err
+	}
+
+	return token.Token, nil
+}
+
+func (t *tokenCache) refresh(scopes []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRefreshTimeout)
+	defer cancel()
+
+	t.fetch(ctx, scopes, true) // nolint:errcheck
+
+	t.mu.Lock()
+	t.refreshing = false
+	t.mu.Unlock()
+}
+
+func (t *tokenCache) fetch(ctx context.Context, scopes []string, force bool) (AccessToken, error) {
+	t.fetchMu.Lock()
+	defer t.fetchMu.Unlock()
+
+	t.mu.Lock()
+	token := t.token
+	t.mu.Unlock()
+
+	if !force && token.Token != "" && time.Now().Before(token.ExpiresOn) {
+		return token, nil
+	}
+
+	token, err := t.credential.GetToken(ctx, scopes)
+	if err != nil {
+		return AccessToken{}, &CosmosError{Code: ErrUnauthorized, Message: "failed to acquire access token: " + err.Error()}
+	}
+
+	t.mu.Lock()
+	t.token = token
+	t.fetchedAt = time.Now()
+	t.mu.Unlock()
+
+	return token, nil
+}
+
+func (t *tokenCache) invalidate(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token.Token == token {
+		t.token = AccessToken{}
+	}
+}
+
+func refreshAt(token AccessToken, fetchedAt time.Time) time.Time {
+	window := tokenRefreshWindow
+	if lifetime := token.ExpiresOn.Sub(fetchedAt); lifetime/2 < window {
+		window = lifetime / 2
+	}
+
+	return token.ExpiresOn.Add(-window)
+}
+
+func (c Client) tokenScopes() []string {
+	return []string{c.endpoint.Scheme + "://" + c.endpoint.Hostname() + "/.default"}
+}
+
+func (c Client) signWithToken(ctx context.Context, req *http.Request) error {
+	token, err := c.credential.get(ctx, c.tokenScopes())
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(api.HEADER_AUTHORIZATION, url.QueryEscape(aadTokenPrefix+token))
+	return nil
+}
+
+func (c Client) invalidateToken(req *http.Request) bool {
+	if c.credential == nil {
+		return false
+	}
+
+	header, err := url.QueryUnescape(req.Header.Get(api.HEADER_AUTHORIZATION))
+	if err != nil || !strings.HasPrefix(header, aadTokenPrefix) {
+		return false
+	}
+
+	c.credential.invalidate(strings.TrimPrefix(header, aadTokenPrefix))
+	return true
+}
+
+type ClientSecretCredential struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	HTTPClient   *http.Client
+}
+
+func NewClientSecretCredential(tokenURL string, clientID string, clientSecret string) *ClientSecretCredential {
+	return &ClientSecretCredential{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   http.DefaultClient,
+	}
+}
+
+type clientCredentialsResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (c *ClientSecretCredential) GetToken(ctx context.Context, scopes []string) (AccessToken, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"scope":         {strings.Join(scopes, " ")},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return AccessToken{}, err
+	}
+	req.Header.Set(api.HEADER_CONTENT_TYPE, "application/x-www-form-urlencoded")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return AccessToken{}, err
+	}
+
+	var token clientCredentialsResponse
+	if err := json.Unmarshal(b, &token); err != nil {
+		return AccessToken{}, err
+	}
+
+	if res.StatusCode != http.StatusOK || token.AccessToken == "" {
+		message := token.ErrorDescription
+		if message == "" {
+			message = token.Error
+		}
+		if message == "" {
+			message = res.Status
+		}
+		return AccessToken{}, &CosmosError{Code: ErrUnauthorized, Message: message}
+	}
+
+	return AccessToken{
+		Token:     token.AccessToken,
+		ExpiresOn: time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}, nil
+}
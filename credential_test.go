@@ -0,0 +1,110 @@
+package cosmos_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func newTokenClient(t *testing.T, lifetime time.Duration, secret string) (*cosmostest.Server, *cosmostest.TokenServer, *cosmos.Client) {
+	t.Helper()
+
+	tokens := cosmostest.NewTokenServer("app", "secret", lifetime)
+	t.Cleanup(tokens.Close)
+
+	server := cosmostest.NewServer(cosmostest.WithTokenServer(tokens))
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(cosmos.WithTokenCredential(cosmos.NewClientSecretCredential(tokens.TokenURL(), "app", secret)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	return server, tokens, client
+}
+
+func TestTokenCredential(t *testing.T) {
+	server, tokens, client := newTokenClient(t, time.Hour, "secret")
+	ctx := context.Background()
+
+	if _, err := client.CreateDatabase(ctx, "bank"); err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("failed to list databases: %v", err)
+	}
+
+	if issued := tokens.Issued(); issued != 1 {
+		t.Errorf("expected the token to be cached, got %d tokens issued", issued)
+	}
+
+	header, _ := url.QueryUnescape(server.LastRequestHeader(api.HEADER_AUTHORIZATION))
+	if !strings.HasPrefix(header, "type=aad&ver=1.0&sig=") {
+		t.Errorf("expected an aad authorization header, got %q", header)
+	}
+}
+
+func TestTokenCredentialRefreshesBeforeExpiry(t *testing.T) {
+	_, tokens, client := newTokenClient(t, 2*time.Second, "secret")
+	ctx := context.Background()
+
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("failed to list databases: %v", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("expected the cached token to be used while refreshing, got %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for tokens.Issued() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if issued := tokens.Issued(); issued != 2 {
+		t.Fatalf("expected the token to be refreshed in the background, got %d tokens issued", issued)
+	}
+
+	time.Sleep(time.Second)
+
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("expected the refreshed token to be used after the original expired, got %v", err)
+	}
+}
+
+func TestTokenCredentialRevoked(t *testing.T) {
+	_, tokens, client := newTokenClient(t, time.Hour, "secret")
+	ctx := context.Background()
+
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("failed to list databases: %v", err)
+	}
+
+	tokens.Revoke()
+
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("expected the request to be retried with a new token, got %v", err)
+	}
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("expected the new token to be cached, got %v", err)
+	}
+	if issued := tokens.Issued(); issued != 2 {
+		t.Errorf("expected 2 tokens issued, got %d", issued)
+	}
+}
+
+func TestTokenCredentialInvalidClient(t *testing.T) {
+	_, _, client := newTokenClient(t, time.Hour, "wrong")
+
+	if _, err := client.ListDatabases(context.Background()); !cosmos.InUnauthorized(err) {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+}
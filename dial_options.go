@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/zhevron/cosmos/api"
 )
 
 type DialOption func(*Client) error
@@ -31,7 +34,7 @@ func WithConnectionString(connectionString string) DialOption {
 		}
 
 		c.endpoint = endpoint
-		c.key = key
+		c.keys.setPrimary(key)
 		return nil
 	}
 }
@@ -43,7 +46,52 @@ func WithKey(key string) DialOption {
 			return err
 		}
 
-		c.key = k
+		c.keys.setPrimary(k)
+		return nil
+	}
+}
+
+func WithSecondaryKey(key string) DialOption {
+	return func(c *Client) error {
+		k, err := ParseKey(key)
+		if err != nil {
+			return err
+		}
+
+		c.keys.setSecondary(k)
+		return nil
+	}
+}
+
+func WithReadOnlyKeys(keys ...string) DialOption {
+	return func(c *Client) error {
+		for _, key := range keys {
+			k, err := ParseKey(key)
+			if err != nil {
+				return err
+			}
+
+			c.keys.addReadOnly(k)
+		}
+		return nil
+	}
+}
+
+func WithTokenCredential(credential TokenCredential) DialOption {
+	return func(c *Client) error {
+		c.credential = newTokenCache(credential)
+		return nil
+	}
+}
+
+func WithResourceTokens(tokens map[string]string) DialOption {
+	return func(c *Client) error {
+		if c.resourceTokens == nil {
+			c.resourceTokens = make(map[string]string, len(tokens))
+		}
+		for link, token := range tokens {
+			c.resourceTokens[strings.Trim(link, "/")] = token
+		}
 		return nil
 	}
 }
@@ -62,6 +110,13 @@ func WithRetries(retries int) DialOption {
 	}
 }
 
+func WithConcurrencyRetries(retries int) DialOption {
+	return func(c *Client) error {
+		c.MaxConcurrencyRetries = retries
+		return nil
+	}
+}
+
 func WithRetryForStatusCode(statusCodes ...int) DialOption {
 	return func(c *Client) error {
 		if c.retryOnStatus == nil {
@@ -72,6 +127,83 @@ func WithRetryForStatusCode(statusCodes ...int) DialOption {
 	}
 }
 
+func WithRetryPolicy(policy RetryPolicy) DialOption {
+	return func(c *Client) error {
+		c.retryPolicy = policy
+		return nil
+	}
+}
+
+func WithMiddleware(middleware ...Middleware) DialOption {
+	return func(c *Client) error {
+		c.middleware = append(c.middleware, middleware...)
+		return nil
+	}
+}
+
+func WithMetricsCollector(collector MetricsCollector) DialOption {
+	return func(c *Client) error {
+		c.metrics = collector
+		return nil
+	}
+}
+
+func WithRateLimiter(limiter *RateLimiter) DialOption {
+	return func(c *Client) error {
+		c.limiter = limiter
+		return nil
+	}
+}
+
+func WithCollectionRateLimiter(databaseID string, collectionID string, limiter *RateLimiter) DialOption {
+	return func(c *Client) error {
+		if c.collectionLimiters == nil {
+			c.collectionLimiters = make(map[string]*RateLimiter)
+		}
+
+		c.collectionLimiters[createCollectionLink(databaseID, collectionID)] = limiter
+		return nil
+	}
+}
+
+func WithCircuitBreaker(breaker *CircuitBreaker) DialOption {
+	return func(c *Client) error {
+		c.breaker = breaker
+		return nil
+	}
+}
+
+func WithEndpointDiscovery(enabled bool) DialOption {
+	return func(c *Client) error {
+		if !enabled {
+			c.locations = nil
+		} else if c.locations == nil {
+			c.locations = newLocationCache()
+		}
+		return nil
+	}
+}
+
+func WithPreferredRegions(regions ...string) DialOption {
+	return func(c *Client) error {
+		if c.locations == nil {
+			c.locations = newLocationCache()
+		}
+		c.locations.preferred = append(c.locations.preferred, regions...)
+		return nil
+	}
+}
+
+func WithLocationRefreshInterval(interval time.Duration) DialOption {
+	return func(c *Client) error {
+		if c.locations == nil {
+			c.locations = newLocationCache()
+		}
+		c.locations.interval = interval
+		return nil
+	}
+}
+
 func WithTimeout(timeout time.Duration) DialOption {
 	return func(c *Client) error {
 		c.client.Timeout = timeout
@@ -100,6 +232,24 @@ func WithQueryMetrics(enableMetrics bool) DialOption {
 	}
 }
 
+func WithDocumentCache(expiration time.Duration) DialOption {
+	return func(c *Client) error {
+		c.documents = cache.New(expiration, 2*expiration)
+		return nil
+	}
+}
+
+func WithConsistencyLevel(level api.ConsistencyLevel) DialOption {
+	return func(c *Client) error {
+		if err := validateConsistencyLevel(level); err != nil {
+			return err
+		}
+
+		c.consistencyLevel = level
+		return nil
+	}
+}
+
 func WithConnectionPooling(enableConnectionPooling bool) DialOption {
 	return func(c *Client) error {
 		c.client.Transport.(*http.Transport).DisableKeepAlives = enableConnectionPooling
@@ -1,6 +1,7 @@
 package cosmos
 
 import (
+	"encoding/json"
 	"reflect"
 	"strings"
 )
@@ -26,6 +27,55 @@ func DocumentID(document interface{}) (string, error) {
 	return "", &CosmosError{Code: ErrNoDocumentID, Message: "unsupported document type"}
 }
 
+func DocumentEtag(document interface{}) string {
+	if doc, ok := document.(Document); ok {
+		return doc.Etag
+	}
+
+	rv := reflect.ValueOf(document)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return ""
+		}
+
+		if v := rv.MapIndex(reflect.ValueOf("_etag").Convert(rv.Type().Key())); v.IsValid() {
+			etag, _ := v.Interface().(string)
+			return etag
+		}
+
+	case reflect.Struct:
+		return extractDocumentEtagFromStruct(rv)
+	}
+
+	return ""
+}
+
+func documentEtagFromJSON(data []byte) string {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return ""
+	}
+
+	return doc.Etag
+}
+
+func resetDocument(document interface{}) {
+	rv := reflect.ValueOf(document)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return
+	}
+
+	rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+}
+
 func extractDocumentIDFromMap(document reflect.Value) (string, error) {
 	keys := document.MapKeys()
 	if len(keys) == 0 {
@@ -73,5 +123,35 @@ func extractDocumentIDFromStruct(document reflect.Value) (string, error) {
 		}
 	}
 
+	for i := 0; i < numField; i++ {
+		if rt.Field(i).Anonymous && rt.Field(i).PkgPath == "" && document.Field(i).Kind() == reflect.Struct {
+			if id, err := extractDocumentIDFromStruct(document.Field(i)); err == nil {
+				return id, nil
+			}
+		}
+	}
+
 	return "", &CosmosError{Code: ErrNoDocumentID, Message: "could not find id field in struct"}
 }
+
+func extractDocumentEtagFromStruct(document reflect.Value) string {
+	rt := document.Type()
+	numField := rt.NumField()
+
+	for i := 0; i < numField; i++ {
+		if strings.Split(rt.Field(i).Tag.Get("json"), ",")[0] == "_etag" && rt.Field(i).PkgPath == "" {
+			etag, _ := document.Field(i).Interface().(string)
+			return etag
+		}
+	}
+
+	for i := 0; i < numField; i++ {
+		if rt.Field(i).Anonymous && rt.Field(i).PkgPath == "" && document.Field(i).Kind() == reflect.Struct {
+			if etag := extractDocumentEtagFromStruct(document.Field(i)); etag != "" {
+				return etag
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,45 @@
+package cosmos
+
+import (
+	"encoding/json"
+
+	"github.com/patrickmn/go-cache"
+)
+
+type cachedDocument struct {
+	etag string
+	body json.RawMessage
+}
+
+func documentCacheKey(link string, partitionKey string) string {
+	return link + "@" + partitionKey
+}
+
+func (c Client) cachedDocument(key string) (*cachedDocument, bool) {
+	if c.documents == nil {
+		return nil, false
+	}
+
+	document, found := c.documents.Get(key)
+	if !found {
+		return nil, false
+	}
+
+	return document.(*cachedDocument), true
+}
+
+func (c Client) cacheDocument(key string, etag string, body json.RawMessage) {
+	if c.documents == nil || etag == "" {
+		return
+	}
+
+	c.documents.Set(key, &cachedDocument{etag: etag, body: body}, cache.DefaultExpiration)
+}
+
+func (c Client) invalidateDocument(key string) {
+	if c.documents == nil {
+		return
+	}
+
+	c.documents.Delete(key)
+}
@@ -18,6 +18,7 @@ type DocumentIterator struct {
 	documents         []json.RawMessage
 	total             int
 	current           int
+	info              ResponseInfo
 	err               error
 }
 
@@ -26,7 +27,7 @@ func newDocumentIterator(ctx context.Context, client *Client, res *http.Response
 	for k := range res.Request.Header {
 		headers[k] = res.Request.Header.Get(k)
 	}
-	delete(headers, "Authorization")
+	delete(headers, api.HEADER_AUTHORIZATION)
 
 	return &DocumentIterator{
 		ctx:               ctx,
@@ -38,6 +39,7 @@ func newDocumentIterator(ctx context.Context, client *Client, res *http.Response
 		documents:         queryResult.Documents,
 		total:             queryResult.Count,
 		current:           0,
+		info:              newResponseInfo(res),
 	}
 }
 
@@ -82,6 +84,10 @@ func (it *DocumentIterator) Count() int {
 	return it.total
 }
 
+func (it *DocumentIterator) ResponseInfo() ResponseInfo {
+	return it.info
+}
+
 func (it *DocumentIterator) Err() error {
 	return it.err
 }
@@ -103,19 +109,19 @@ func (it *DocumentIterator) fetchNext() error {
 	it.headers[api.HEADER_CONTINUATION] = it.continuationTokan
 
 	var result api.ListDocumentsResponse
+	var res *http.Response
+	var err error
 	if it.query == nil {
-		res, err := it.client.get(it.ctx, it.path, &result, it.headers)
-		if err != nil {
-			return err
-		}
-		it.continuationTokan = res.Header.Get(api.HEADER_CONTINUATION)
+		res, err = it.client.get(it.ctx, it.path, &result, it.headers)
 	} else {
-		res, err := it.client.post(it.ctx, it.path, it.query, &result, it.headers)
-		if err != nil {
-			return err
-		}
-		it.continuationTokan = res.Header.Get(api.HEADER_CONTINUATION)
+		res, err = it.client.post(it.ctx, it.path, it.query, &result, it.headers)
 	}
+	if err != nil {
+		return err
+	}
+
+	it.continuationTokan = res.Header.Get(api.HEADER_CONTINUATION)
+	it.info.add(newResponseInfo(res))
 
 	it.documents = append(it.documents, result.Documents...)
 	return nil
@@ -0,0 +1,155 @@
+package cosmos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"math/bits"
+	"reflect"
+	"strings"
+)
+
+const (
+	partitionKeyUndefined byte = 0x00
+	partitionKeyNull      byte = 0x01
+	partitionKeyFalse     byte = 0x02
+	partitionKeyTrue      byte = 0x03
+	partitionKeyNumber    byte = 0x05
+	partitionKeyString    byte = 0x08
+)
+
+func EffectivePartitionKey(components ...interface{}) string {
+	var buf bytes.Buffer
+	for _, component := range components {
+		writePartitionKeyComponent(&buf, component)
+	}
+
+	h1, h2 := murmur3(buf.Bytes())
+
+	hash := make([]byte, 16)
+	binary.BigEndian.PutUint64(hash[:8], h2)
+	binary.BigEndian.PutUint64(hash[8:], h1)
+	hash[0] &= 0x3F
+
+	return strings.ToUpper(hex.EncodeToString(hash))
+}
+
+func writePartitionKeyComponent(buf *bytes.Buffer, component interface{}) {
+	switch v := component.(type) {
+	case nil:
+		buf.WriteByte(partitionKeyNull)
+	case bool:
+		if v {
+			buf.WriteByte(partitionKeyTrue)
+		} else {
+			buf.WriteByte(partitionKeyFalse)
+		}
+	case string:
+		buf.WriteByte(partitionKeyString)
+		buf.WriteString(v)
+		buf.WriteByte(0xFF)
+	default:
+		number, ok := partitionKeyNumberValue(v)
+		if !ok {
+			buf.WriteByte(partitionKeyUndefined)
+			return
+		}
+
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, math.Float64bits(number))
+		buf.WriteByte(partitionKeyNumber)
+		buf.Write(b)
+	}
+}
+
+func partitionKeyNumberValue(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func murmur3(data []byte) (uint64, uint64) {
+	const (
+		c1 = 0x87c37b91114253d5
+		c2 = 0x4cf5ad432745937f
+	)
+
+	var h1, h2 uint64
+	length := len(data)
+
+	for len(data) >= 16 {
+		k1 := binary.LittleEndian.Uint64(data[:8])
+		k2 := binary.LittleEndian.Uint64(data[8:16])
+		data = data[16:]
+
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	var k1, k2 uint64
+	for i := len(data) - 1; i >= 8; i-- {
+		k2 ^= uint64(data[i]) << (uint(i-8) * 8)
+	}
+	if len(data) > 8 {
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+	}
+
+	for i := 0; i < len(data) && i < 8; i++ {
+		k1 ^= uint64(data[i]) << (uint(i) * 8)
+	}
+	if len(data) > 0 {
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+	}
+
+	h1 ^= uint64(length)
+	h2 ^= uint64(length)
+
+	h1 += h2
+	h2 += h1
+
+	h1 = murmur3Mix(h1)
+	h2 = murmur3Mix(h2)
+
+	h1 += h2
+	h2 += h1
+
+	return h1, h2
+}
+
+func murmur3Mix(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
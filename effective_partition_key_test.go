@@ -0,0 +1,42 @@
+package cosmos_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+)
+
+func TestEffectivePartitionKey(t *testing.T) {
+	// Known answers for hash V2 partition keys from the Azure Cosmos DB Java SDK (PartitionKeyInternalTest).
+	tests := []struct {
+		name      string
+		component interface{}
+		expected  string
+	}{
+		{"empty string", "", "32E9366E637A71B4E710384B2F4970A0"},
+		{"string", "partitionKey", "013AEFCF77FA271571CF665A58C933F1"},
+		{"long string", strings.Repeat("a", 1024), "332BDF5512AE49615F32C7D98C2DB86C"},
+		{"null", nil, "378867E4430E67857ACE5C908374FE16"},
+		{"undefined", struct{}{}, "11622DAA78F835834610ABE56EFF5CB5"},
+		{"true", true, "0E711127C5B5A8E4726AC6DD306A3E59"},
+		{"false", false, "2FE1BE91E90A3439635E0E9E37361EF2"},
+		{"min int8", int8(math.MinInt8), "01DAEDABF913540367FE219B2AD06148"},
+		{"max int8", int8(math.MaxInt8), "0C507ACAC853ECA7977BF4CEFB562A25"},
+		{"min int32", int32(math.MinInt32), "0B1660D5233C3171725B30D4A5F4CC1F"},
+		{"max int32", int32(math.MaxInt32), "2D9349D64712AEB5EB1406E2F0BE2725"},
+		{"min int64", int64(math.MinInt64), "23D5C6395512BDFEAFADAD15328AD2BB"},
+		{"max int64", int64(math.MaxInt64), "2EDB959178DFCCA18983F89384D1629B"},
+		{"smallest float64", math.SmallestNonzeroFloat64, "0E6CBA63A280927DE485DEF865800139"},
+		{"max float64", math.MaxFloat64, "31424D996457102634591FF245DBCC4D"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if epk := cosmos.EffectivePartitionKey(tt.component); epk != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, epk)
+			}
+		})
+	}
+}
@@ -18,11 +18,16 @@ const (
 	ErrConcurrency         ErrorCode = 8
 	ErrDocumentTooLarge    ErrorCode = 9
 	ErrInternalServerError ErrorCode = 10
+	ErrGone                ErrorCode = 11
+	ErrTooManyRequests     ErrorCode = 12
+	ErrCircuitOpen         ErrorCode = 13
 )
 
 type CosmosError struct {
-	Code    ErrorCode
-	Message string
+	Code              ErrorCode
+	Message           string
+	OperationIndex    int
+	HasOperationIndex bool
 }
 
 func (e *CosmosError) Error() string {
@@ -73,6 +78,18 @@ func IsInternalServerError(err error) bool {
 	return isErrorCode(err, ErrInternalServerError)
 }
 
+func IsGone(err error) bool {
+	return isErrorCode(err, ErrGone)
+}
+
+func IsTooManyRequests(err error) bool {
+	return isErrorCode(err, ErrTooManyRequests)
+}
+
+func IsCircuitOpen(err error) bool {
+	return isErrorCode(err, ErrCircuitOpen)
+}
+
 func isErrorCode(err error, code ErrorCode) bool {
 	if cerr, ok := err.(*CosmosError); ok {
 		return cerr.Code == code
@@ -1,6 +1,6 @@
 module github.com/zhevron/cosmos
 
-go 1.15
+go 1.21
 
 require (
 	github.com/opentracing/opentracing-go v1.2.0
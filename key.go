@@ -1,9 +1,11 @@
 package cosmos
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
+	"sync"
 )
 
 type Key []byte
@@ -27,3 +29,100 @@ func (k Key) Sign(data []byte) string {
 	b := h.Sum(nil)
 	return base64.StdEncoding.EncodeToString(b)
 }
+
+type keyRing struct {
+	mu        sync.RWMutex
+	primary   Key
+	secondary Key
+	readOnly  []Key
+}
+
+func (r *keyRing) setPrimary(key Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.primary = key
+}
+
+func (r *keyRing) setSecondary(key Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.secondary = key
+}
+
+func (r *keyRing) addReadOnly(keys ...Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.readOnly = append(r.readOnly, keys...)
+}
+
+func (r *keyRing) rotate(key Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.primary) > 0 {
+		r.secondary = r.primary
+	}
+	r.primary = key
+}
+
+func (r *keyRing) empty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.primary) == 0 && len(r.secondary) == 0 && len(r.readOnly) == 0
+}
+
+func (r *keyRing) key(index int, read bool) (Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := r.keys(read)
+	if index < len(keys) {
+		return keys[index], true
+	}
+
+	return nil, index == 0
+}
+
+func (r *keyRing) keys(read bool) []Key {
+	var keys []Key
+	for _, key := range []Key{r.primary, r.secondary} {
+		if len(key) > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	if read || len(keys) == 0 {
+		keys = append(keys, r.readOnly...)
+	}
+
+	return keys
+}
+
+func (r *keyRing) promote(key Key, read bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if bytes.Equal(key, r.secondary) {
+		r.primary, r.secondary = r.secondary, r.primary
+	}
+
+	for i, k := range r.readOnly {
+		if i > 0 && bytes.Equal(k, key) {
+			copy(r.readOnly[1:i+1], r.readOnly[:i])
+			r.readOnly[0] = key
+			break
+		}
+	}
+
+	for i, k := range r.keys(read) {
+		if bytes.Equal(k, key) {
+			return i
+		}
+	}
+
+	return 0
+}
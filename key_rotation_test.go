@@ -0,0 +1,177 @@
+package cosmos_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func newAccountKey(t *testing.T) string {
+	t.Helper()
+
+	b := make([]byte, 64)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func unauthorizedRequests(metrics *cosmos.InMemoryMetrics) int {
+	var count int
+	for key, n := range metrics.Snapshot().Requests {
+		if key.StatusCode == http.StatusUnauthorized {
+			count += n
+		}
+	}
+
+	return count
+}
+
+func TestRotateKey(t *testing.T) {
+	primary, secondary := newAccountKey(t), newAccountKey(t)
+
+	server := cosmostest.NewServer(cosmostest.WithKey(primary), cosmostest.WithSecondaryKey(secondary))
+	t.Cleanup(server.Close)
+
+	metrics := cosmos.NewInMemoryMetrics()
+	client, err := server.Client(cosmos.WithSecondaryKey(secondary), cosmos.WithMetricsCollector(metrics))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.CreateDatabase(ctx, "bank"); err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	regenerated := newAccountKey(t)
+	server.SetKeys(regenerated, secondary)
+
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("expected the secondary key to be used, got %v", err)
+	}
+	if n := unauthorizedRequests(metrics); n != 1 {
+		t.Fatalf("expected 1 rejected request, got %d", n)
+	}
+
+	if _, err := client.ListDatabases(ctx); err != nil {
+		t.Fatalf("failed to list databases: %v", err)
+	}
+	if n := unauthorizedRequests(metrics); n != 1 {
+		t.Errorf("expected the secondary key to be promoted, got %d rejected requests", n)
+	}
+
+	if err := client.RotateKey(regenerated); err != nil {
+		t.Fatalf("failed to rotate key: %v", err)
+	}
+	server.SetKeys(regenerated, newAccountKey(t))
+
+	if _, err := client.GetDatabase(ctx, "missing"); !cosmos.IsNotFound(err) {
+		t.Fatalf("expected the rotated key to be used, got %v", err)
+	}
+	if n := unauthorizedRequests(metrics); n != 1 {
+		t.Errorf("expected no further rejected requests, got %d", n)
+	}
+
+	server.SetKeys(newAccountKey(t), newAccountKey(t))
+	if _, err := client.ListDatabases(ctx); !cosmos.InUnauthorized(err) {
+		t.Errorf("expected unauthorized once both keys are regenerated, got %v", err)
+	}
+}
+
+func TestSecondaryKeyRememberedOnError(t *testing.T) {
+	primary, secondary := newAccountKey(t), newAccountKey(t)
+
+	server := cosmostest.NewServer(cosmostest.WithKey(primary), cosmostest.WithSecondaryKey(secondary))
+	t.Cleanup(server.Close)
+
+	metrics := cosmos.NewInMemoryMetrics()
+	client, err := server.Client(cosmos.WithSecondaryKey(secondary), cosmos.WithMetricsCollector(metrics))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	server.SetKeys(newAccountKey(t), secondary)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetDatabase(ctx, "missing"); !cosmos.IsNotFound(err) {
+			t.Fatalf("expected not found with the secondary key, got %v", err)
+		}
+	}
+
+	if n := unauthorizedRequests(metrics); n != 1 {
+		t.Errorf("expected the secondary key to be kept after the first fallback, got %d rejected requests", n)
+	}
+}
+
+func TestRotateKeyConcurrently(t *testing.T) {
+	keys := []string{newAccountKey(t), newAccountKey(t)}
+
+	server := cosmostest.NewServer(cosmostest.WithKey(keys[0]), cosmostest.WithSecondaryKey(keys[1]))
+	t.Cleanup(server.Close)
+
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if err := client.RotateKey(keys[i%2]); err != nil {
+				t.Errorf("failed to rotate key: %v", err)
+			}
+			if _, err := client.ListDatabases(ctx); err != nil {
+				t.Errorf("failed to list databases: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := client.RotateKey("not a key"); !cosmos.IsInvalidKey(err) {
+		t.Errorf("expected invalid key, got %v", err)
+	}
+}
+
+func TestReadOnlyKeys(t *testing.T) {
+	readOnly := newAccountKey(t)
+
+	server := cosmostest.NewServer(cosmostest.WithReadOnlyKeys(readOnly))
+	t.Cleanup(server.Close)
+
+	admin, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := admin.CreateDatabase(ctx, "bank"); err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	endpoint, _ := url.Parse(server.URL)
+	client, err := cosmos.Dial(cosmos.WithEndpoint(endpoint), cosmos.WithReadOnlyKeys(readOnly))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if _, err := client.GetDatabase(ctx, "bank"); err != nil {
+		t.Fatalf("expected reads with a read-only key, got %v", err)
+	}
+	if _, err := client.CreateDatabase(ctx, "ledger"); !cosmos.IsForbidden(err) {
+		t.Errorf("expected writes with a read-only key to be forbidden, got %v", err)
+	}
+}
@@ -35,3 +35,52 @@ func createAttachmentLink(databaseID string, collectionID string, documentID str
 
 	return link
 }
+
+func createPartitionKeyRangeLink(databaseID string, collectionID string) string {
+	return createCollectionLink(databaseID, collectionID) + "/pkranges"
+}
+
+func createStoredProcedureLink(databaseID string, collectionID string, storedProcedureID string) string {
+	link := createCollectionLink(databaseID, collectionID) + "/sprocs"
+	if len(storedProcedureID) > 0 {
+		link += "/" + storedProcedureID
+	}
+
+	return link
+}
+
+func createTriggerLink(databaseID string, collectionID string, triggerID string) string {
+	link := createCollectionLink(databaseID, collectionID) + "/triggers"
+	if len(triggerID) > 0 {
+		link += "/" + triggerID
+	}
+
+	return link
+}
+
+func createUserDefinedFunctionLink(databaseID string, collectionID string, functionID string) string {
+	link := createCollectionLink(databaseID, collectionID) + "/udfs"
+	if len(functionID) > 0 {
+		link += "/" + functionID
+	}
+
+	return link
+}
+
+func createUserLink(databaseID string, userID string) string {
+	link := createDatabaseLink(databaseID) + "/users"
+	if len(userID) > 0 {
+		link += "/" + userID
+	}
+
+	return link
+}
+
+func createPermissionLink(databaseID string, userID string, permissionID string) string {
+	link := createUserLink(databaseID, userID) + "/permissions"
+	if len(permissionID) > 0 {
+		link += "/" + permissionID
+	}
+
+	return link
+}
@@ -0,0 +1,238 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const (
+	substatusWriteForbidden          = 3
+	substatusDatabaseAccountNotFound = 1008
+	defaultLocationRefreshInterval   = 5 * time.Minute
+	locationUnavailableExpiration    = 5 * time.Minute
+)
+
+type failoverReason int
+
+const (
+	noFailover failoverReason = iota
+	failoverUnreachable
+	failoverWriteForbidden
+	failoverAccountNotFound
+)
+
+type location struct {
+	name     string
+	endpoint *url.URL
+}
+
+type locationCache struct {
+	mu                sync.Mutex
+	preferred         []string
+	interval          time.Duration
+	refreshedAt       time.Time
+	refreshing        bool
+	readable          []location
+	writable          []location
+	multiWrite        bool
+	unavailableReads  map[string]time.Time
+	unavailableWrites map[string]time.Time
+}
+
+func newLocationCache() *locationCache {
+	return &locationCache{
+		interval:          defaultLocationRefreshInterval,
+		unavailableReads:  make(map[string]time.Time),
+		unavailableWrites: make(map[string]time.Time),
+	}
+}
+
+func (l *locationCache) update(account *api.DatabaseAccount) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.readable = parseLocations(account.ReadableLocations)
+	l.writable = parseLocations(account.WritableLocations)
+	l.multiWrite = account.EnableMultipleWriteLocations
+	l.refreshedAt = time.Now()
+}
+
+func parseLocations(locations []api.DatabaseAccountLocation) []location {
+	parsed := make([]location, 0, len(locations))
+	for _, loc := range locations {
+		endpoint, err := url.Parse(loc.DatabaseAccountEndpoint)
+		if err != nil || endpoint.Host == "" {
+			continue
+		}
+		parsed = append(parsed, location{name: loc.Name, endpoint: endpoint})
+	}
+
+	return parsed
+}
+
+func (l *locationCache) stale() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.refreshing || time.Since(l.refreshedAt) < l.interval {
+		return false
+	}
+
+	l.refreshing = true
+	l.refreshedAt = time.Now()
+	return true
+}
+
+func (l *locationCache) refresh(ctx context.Context, c Client) {
+	defer func() {
+		l.mu.Lock()
+		l.refreshing = false
+		l.mu.Unlock()
+	}()
+
+	c.GetDatabaseAccount(ctx) // nolint:errcheck
+}
+
+func (l *locationCache) endpoints(read bool) []*url.URL {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	locations, unavailable := l.readable, l.unavailableReads
+	if !read {
+		locations, unavailable = l.writable, l.unavailableWrites
+		if !l.multiWrite && len(locations) > 1 {
+			locations = locations[:1]
+		}
+	}
+
+	var available, unreachable []*url.URL
+	for _, loc := range l.ordered(locations) {
+		if markedAt, ok := unavailable[loc.endpoint.Host]; ok && time.Since(markedAt) < locationUnavailableExpiration {
+			unreachable = append(unreachable, loc.endpoint)
+			continue
+		}
+		available = append(available, loc.endpoint)
+	}
+
+	return append(available, unreachable...)
+}
+
+func (l *locationCache) ordered(locations []location) []location {
+	ordered := make([]location, 0, len(locations))
+	used := make([]bool, len(locations))
+	for _, name := range l.preferred {
+		for i, loc := range locations {
+			if !used[i] && strings.EqualFold(loc.name, name) {
+				ordered = append(ordered, loc)
+				used[i] = true
+			}
+		}
+	}
+
+	for i, loc := range locations {
+		if !used[i] {
+			ordered = append(ordered, loc)
+		}
+	}
+
+	return ordered
+}
+
+func (l *locationCache) markUnavailable(endpoint *url.URL, reason failoverReason) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	switch reason {
+	case failoverWriteForbidden:
+		l.unavailableWrites[endpoint.Host] = now
+	case failoverUnreachable, failoverAccountNotFound:
+		l.unavailableReads[endpoint.Host] = now
+		l.unavailableWrites[endpoint.Host] = now
+	}
+
+	if reason != failoverUnreachable {
+		l.refreshedAt = time.Time{}
+	}
+}
+
+func (c Client) endpointsFor(ctx context.Context, link string, read bool, tried map[string]bool) []*url.URL {
+	if c.locations == nil || link == "" {
+		if tried[c.endpoint.Host] {
+			return nil
+		}
+		return []*url.URL{c.endpoint}
+	}
+
+	if c.locations.stale() {
+		c.locations.refresh(ctx, c)
+	}
+
+	endpoints := c.locations.endpoints(read)
+	if len(endpoints) == 0 {
+		endpoints = []*url.URL{c.endpoint}
+	}
+
+	filtered := endpoints[:0]
+	for _, endpoint := range endpoints {
+		if !tried[endpoint.Host] {
+			filtered = append(filtered, endpoint)
+		}
+	}
+
+	return filtered
+}
+
+func failoverReasonFor(ctx context.Context, res *http.Response, err error) failoverReason {
+	if ctx.Err() != nil {
+		return noFailover
+	}
+
+	if res == nil {
+		if isTransportError(err) {
+			return failoverUnreachable
+		}
+		return noFailover
+	}
+
+	if res.StatusCode != http.StatusForbidden {
+		return noFailover
+	}
+
+	switch substatus, _ := strconv.Atoi(res.Header.Get(api.HEADER_SUBSTATUS)); substatus {
+	case substatusWriteForbidden:
+		return failoverWriteForbidden
+	case substatusDatabaseAccountNotFound:
+		return failoverAccountNotFound
+	}
+
+	return noFailover
+}
+
+func isTransportError(err error) bool {
+	var netErr net.Error
+	var urlErr *url.Error
+
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
+
+type failoverRetryPolicy struct {
+	RetryPolicy
+}
+
+func (p failoverRetryPolicy) ShouldRetry(attempt RetryAttempt) (time.Duration, bool) {
+	if attempt.Response == nil && isTransportError(attempt.Err) {
+		return 0, false
+	}
+
+	return p.RetryPolicy.ShouldRetry(attempt)
+}
@@ -0,0 +1,86 @@
+package cosmos
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const redacted = "REDACTED"
+
+type requestLogEntry struct {
+	operation     string
+	method        string
+	link          string
+	attempt       int
+	statusCode    int
+	substatus     int
+	requestCharge float64
+	duration      time.Duration
+	header        http.Header
+	body          []byte
+	err           error
+}
+
+type requestLogger interface {
+	logRequest(ctx context.Context, entry requestLogEntry)
+}
+
+func (c Client) logRequest(ctx context.Context, req *http.Request, body []byte, res *http.Response, err error, attempt int, duration time.Duration) {
+	if c.logger == nil {
+		return
+	}
+
+	entry := requestLogEntry{
+		operation: operationFromContext(ctx),
+		method:    req.Method,
+		link:      req.URL.Path,
+		attempt:   attempt,
+		duration:  duration,
+		header:    redactHeader(req.Header),
+		body:      body,
+		err:       err,
+	}
+
+	if res != nil {
+		entry.statusCode = res.StatusCode
+		entry.substatus, _ = strconv.Atoi(res.Header.Get(api.HEADER_SUBSTATUS))
+		entry.requestCharge, _ = strconv.ParseFloat(res.Header.Get(api.HEADER_REQUEST_CHARGE), 64)
+	}
+
+	c.logger.logRequest(ctx, entry)
+}
+
+func redactHeader(header http.Header) http.Header {
+	redactedHeader := header.Clone()
+	if redactedHeader.Get(api.HEADER_AUTHORIZATION) != "" {
+		redactedHeader.Set(api.HEADER_AUTHORIZATION, redacted)
+	}
+
+	return redactedHeader
+}
+
+func redactFields(value interface{}, fields map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if fields[k] {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactFields(child, fields)
+		}
+		return v
+
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactFields(child, fields)
+		}
+		return v
+	}
+
+	return value
+}
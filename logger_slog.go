@@ -0,0 +1,121 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type slogLogger struct {
+	handler      slog.Handler
+	successLevel slog.Level
+	failureLevel slog.Level
+	headers      bool
+	body         bool
+	fields       map[string]bool
+}
+
+type LoggerOption func(*slogLogger)
+
+func LoggerLevels(success slog.Level, failure slog.Level) LoggerOption {
+	return func(l *slogLogger) {
+		l.successLevel = success
+		l.failureLevel = failure
+	}
+}
+
+func LoggerIncludeHeaders(enabled bool) LoggerOption {
+	return func(l *slogLogger) {
+		l.headers = enabled
+	}
+}
+
+func LoggerIncludeBody(enabled bool) LoggerOption {
+	return func(l *slogLogger) {
+		l.body = enabled
+	}
+}
+
+func LoggerRedactFields(fields ...string) LoggerOption {
+	return func(l *slogLogger) {
+		for _, field := range fields {
+			l.fields[field] = true
+		}
+	}
+}
+
+func WithLogger(handler slog.Handler, opts ...LoggerOption) DialOption {
+	return func(c *Client) error {
+		logger := &slogLogger{
+			handler:      handler,
+			successLevel: slog.LevelDebug,
+			failureLevel: slog.LevelWarn,
+			fields:       make(map[string]bool),
+		}
+
+		for _, opt := range opts {
+			opt(logger)
+		}
+
+		c.logger = logger
+		return nil
+	}
+}
+
+func (l *slogLogger) logRequest(ctx context.Context, entry requestLogEntry) {
+	level := l.successLevel
+	if entry.err != nil || entry.statusCode >= http.StatusBadRequest {
+		level = l.failureLevel
+	}
+
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", entry.operation),
+		slog.String("method", entry.method),
+		slog.String("link", entry.link),
+		slog.Int("attempt", entry.attempt),
+		slog.Int("status", entry.statusCode),
+		slog.Int("substatus", entry.substatus),
+		slog.Float64("request_charge", entry.requestCharge),
+		slog.Duration("duration", entry.duration),
+	}
+
+	if entry.err != nil {
+		attrs = append(attrs, slog.String("error", entry.err.Error()))
+	}
+
+	if l.headers {
+		headers := make([]any, 0, len(entry.header))
+		for name := range entry.header {
+			headers = append(headers, slog.String(name, entry.header.Get(name)))
+		}
+		attrs = append(attrs, slog.Group("headers", headers...))
+	}
+
+	if l.body && len(entry.body) > 0 {
+		attrs = append(attrs, slog.String("body", l.redactBody(entry.body)))
+	}
+
+	record := slog.NewRecord(time.Now(), level, "cosmos request", 0)
+	record.AddAttrs(attrs...)
+	l.handler.Handle(ctx, record) // nolint:errcheck
+}
+
+func (l *slogLogger) redactBody(body []byte) string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return redacted
+	}
+
+	b, err := json.Marshal(redactFields(value, l.fields))
+	if err != nil {
+		return redacted
+	}
+
+	return string(b)
+}
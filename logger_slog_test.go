@@ -0,0 +1,76 @@
+package cosmos_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	server, coll := newTestCollection(t, cosmos.WithLogger(handler,
+		cosmos.LoggerLevels(slog.LevelInfo, slog.LevelError),
+		cosmos.LoggerIncludeHeaders(true),
+		cosmos.LoggerIncludeBody(true),
+		cosmos.LoggerRedactFields("owner"),
+	))
+	ctx := context.Background()
+
+	buf.Reset()
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice", Balance: 42}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	server.Throttle(1)
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "missing", &acc); !cosmos.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("failed to decode log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("expected 3 log records, got %d:\n%s", len(records), buf.String())
+	}
+
+	create := records[0]
+	if create["level"] != "INFO" || create["operation"] != "CreateDocument" || create["method"] != "POST" ||
+		create["link"] != "/dbs/bank/colls/accounts/docs" || create["status"] != float64(201) || create["request_charge"] != float64(1) {
+		t.Errorf("unexpected create record %v", create)
+	}
+
+	headers, _ := create["headers"].(map[string]interface{})
+	if headers["Authorization"] != "REDACTED" {
+		t.Errorf("expected authorization header to be redacted, got %v", headers["Authorization"])
+	}
+
+	body, _ := create["body"].(string)
+	if strings.Contains(body, "alice") || !strings.Contains(body, `"owner":"REDACTED"`) || !strings.Contains(body, `"balance":42`) {
+		t.Errorf("expected owner to be redacted from body, got %s", body)
+	}
+
+	if throttled := records[1]; throttled["level"] != "ERROR" || throttled["status"] != float64(429) || throttled["attempt"] != float64(0) {
+		t.Errorf("unexpected throttled record %v", throttled)
+	}
+	if missing := records[2]; missing["level"] != "ERROR" || missing["status"] != float64(404) || missing["attempt"] != float64(1) {
+		t.Errorf("unexpected not found record %v", missing)
+	}
+	if strings.Contains(buf.String(), "type%3Dmaster") {
+		t.Errorf("expected no authorization token in logs")
+	}
+}
@@ -0,0 +1,258 @@
+package cosmos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type RequestMetrics struct {
+	Operation     string
+	Method        string
+	ResourceType  string
+	StatusCode    int
+	RequestCharge float64
+	Duration      time.Duration
+	BytesSent     int64
+	BytesReceived int64
+	Err           error
+}
+
+type RetryMetrics struct {
+	Operation  string
+	StatusCode int
+	Attempt    int
+	Wait       time.Duration
+	Err        error
+}
+
+type MetricsCollector interface {
+	ObserveRequest(metrics RequestMetrics)
+	ObserveRetry(metrics RetryMetrics)
+}
+
+type MetricsKey struct {
+	Operation  string
+	StatusCode int
+}
+
+type OperationMetrics struct {
+	RequestCharge float64
+	Duration      time.Duration
+	Retries       int
+}
+
+type MetricsSnapshot struct {
+	Requests      map[MetricsKey]int
+	Operations    map[string]OperationMetrics
+	Throttled     int
+	RetryWait     time.Duration
+	BytesSent     int64
+	BytesReceived int64
+}
+
+type InMemoryMetrics struct {
+	mu       sync.Mutex
+	snapshot MetricsSnapshot
+}
+
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{
+		snapshot: newMetricsSnapshot(),
+	}
+}
+
+func newMetricsSnapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		Requests:   make(map[MetricsKey]int),
+		Operations: make(map[string]OperationMetrics),
+	}
+}
+
+func (m *InMemoryMetrics) ObserveRequest(metrics RequestMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshot.Requests[MetricsKey{Operation: metrics.Operation, StatusCode: metrics.StatusCode}]++
+	operation := m.snapshot.Operations[metrics.Operation]
+	operation.RequestCharge += metrics.RequestCharge
+	operation.Duration += metrics.Duration
+	m.snapshot.Operations[metrics.Operation] = operation
+
+	m.snapshot.BytesSent += metrics.BytesSent
+	m.snapshot.BytesReceived += metrics.BytesReceived
+
+	if metrics.StatusCode == http.StatusTooManyRequests {
+		m.snapshot.Throttled++
+	}
+}
+
+func (m *InMemoryMetrics) ObserveRetry(metrics RetryMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	operation := m.snapshot.Operations[metrics.Operation]
+	operation.Retries++
+	m.snapshot.Operations[metrics.Operation] = operation
+
+	m.snapshot.RetryWait += metrics.Wait
+}
+
+func (m *InMemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.snapshot
+	snapshot.Requests = make(map[MetricsKey]int, len(m.snapshot.Requests))
+	for k, v := range m.snapshot.Requests {
+		snapshot.Requests[k] = v
+	}
+	snapshot.Operations = make(map[string]OperationMetrics, len(m.snapshot.Operations))
+	for k, v := range m.snapshot.Operations {
+		snapshot.Operations[k] = v
+	}
+
+	return snapshot
+}
+
+func (m *InMemoryMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshot = newMetricsSnapshot()
+}
+
+func (m *InMemoryMetrics) WritePrometheus(w io.Writer) error {
+	s := m.Snapshot()
+	p := &prometheusWriter{w: w}
+
+	keys := make([]MetricsKey, 0, len(s.Requests))
+	for k := range s.Requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Operation != keys[j].Operation {
+			return keys[i].Operation < keys[j].Operation
+		}
+		return keys[i].StatusCode < keys[j].StatusCode
+	})
+
+	p.header("cosmos_requests_total", "counter", "Cosmos DB requests by operation and status code.")
+	for _, k := range keys {
+		p.sample("cosmos_requests_total", fmt.Sprintf(`operation=%q,status_code="%d"`, k.Operation, k.StatusCode), float64(s.Requests[k]))
+	}
+
+	operations := make([]string, 0, len(s.Operations))
+	for operation := range s.Operations {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	p.header("cosmos_request_charge_total", "counter", "Request units consumed by operation.")
+	for _, operation := range operations {
+		p.sample("cosmos_request_charge_total", fmt.Sprintf(`operation=%q`, operation), s.Operations[operation].RequestCharge)
+	}
+
+	p.header("cosmos_request_duration_seconds_total", "counter", "Time spent waiting for Cosmos DB responses by operation.")
+	for _, operation := range operations {
+		p.sample("cosmos_request_duration_seconds_total", fmt.Sprintf(`operation=%q`, operation), s.Operations[operation].Duration.Seconds())
+	}
+
+	p.header("cosmos_retries_total", "counter", "Retried Cosmos DB requests by operation.")
+	for _, operation := range operations {
+		p.sample("cosmos_retries_total", fmt.Sprintf(`operation=%q`, operation), float64(s.Operations[operation].Retries))
+	}
+
+	p.header("cosmos_throttled_requests_total", "counter", "Cosmos DB requests rejected with status 429.")
+	p.sample("cosmos_throttled_requests_total", "", float64(s.Throttled))
+
+	p.header("cosmos_retry_wait_seconds_total", "counter", "Time spent waiting between retries.")
+	p.sample("cosmos_retry_wait_seconds_total", "", s.RetryWait.Seconds())
+
+	p.header("cosmos_sent_bytes_total", "counter", "Request body bytes sent to Cosmos DB.")
+	p.sample("cosmos_sent_bytes_total", "", float64(s.BytesSent))
+
+	p.header("cosmos_received_bytes_total", "counter", "Response body bytes received from Cosmos DB.")
+	p.sample("cosmos_received_bytes_total", "", float64(s.BytesReceived))
+
+	return p.err
+}
+
+func (m *InMemoryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(api.HEADER_CONTENT_TYPE, "text/plain; version=0.0.4")
+	m.WritePrometheus(w) // nolint:errcheck
+}
+
+type prometheusWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *prometheusWriter) header(name string, metricType string, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func (p *prometheusWriter) sample(name string, labels string, value float64) {
+	if labels != "" {
+		name += "{" + labels + "}"
+	}
+	p.printf("%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (p *prometheusWriter) printf(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+func (c Client) observeRequest(ctx context.Context, req *http.Request, body []byte, res *http.Response, err error, duration time.Duration) {
+	if c.metrics == nil {
+		return
+	}
+
+	resourceType, _ := resourceTypeFromLink(req.URL.Path)
+	metrics := RequestMetrics{
+		Operation:    operationFromContext(ctx),
+		Method:       req.Method,
+		ResourceType: resourceType,
+		Duration:     duration,
+		BytesSent:    int64(len(body)),
+		Err:          err,
+	}
+
+	if res != nil {
+		metrics.StatusCode = res.StatusCode
+		metrics.RequestCharge, _ = strconv.ParseFloat(res.Header.Get(api.HEADER_REQUEST_CHARGE), 64)
+		if res.ContentLength > 0 {
+			metrics.BytesReceived = res.ContentLength
+		}
+	}
+
+	c.metrics.ObserveRequest(metrics)
+}
+
+func (c Client) observeRetry(ctx context.Context, res *http.Response, err error, attempt int, wait time.Duration) {
+	if c.metrics == nil {
+		return
+	}
+
+	metrics := RetryMetrics{
+		Operation: operationFromContext(ctx),
+		Attempt:   attempt,
+		Wait:      wait,
+		Err:       err,
+	}
+
+	if res != nil {
+		metrics.StatusCode = res.StatusCode
+	}
+
+	c.metrics.ObserveRetry(metrics)
+}
@@ -0,0 +1,77 @@
+package cosmos_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	metrics := cosmos.NewInMemoryMetrics()
+	server, coll := newTestCollection(t, cosmos.WithMetricsCollector(metrics))
+	ctx := context.Background()
+
+	metrics.Reset()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	server.Throttle(2)
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "missing", &acc); !cosmos.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	snapshot := metrics.Snapshot()
+	for key, want := range map[cosmos.MetricsKey]int{
+		{Operation: "CreateDocument", StatusCode: http.StatusCreated}:      1,
+		{Operation: "GetDocument", StatusCode: http.StatusTooManyRequests}: 2,
+		{Operation: "GetDocument", StatusCode: http.StatusOK}:              1,
+		{Operation: "GetDocument", StatusCode: http.StatusNotFound}:        1,
+	} {
+		if got := snapshot.Requests[key]; got != want {
+			t.Errorf("expected %d requests for %+v, got %d", want, key, got)
+		}
+	}
+
+	if snapshot.Throttled != 2 || snapshot.Operations["GetDocument"].Retries != 2 || snapshot.RetryWait <= 0 {
+		t.Errorf("expected 2 throttled and retried requests, got %+v", snapshot)
+	}
+	if snapshot.Operations["CreateDocument"].RequestCharge != 1 || snapshot.BytesSent == 0 || snapshot.BytesReceived == 0 {
+		t.Errorf("unexpected charge and byte counters %+v", snapshot)
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.WritePrometheus(&buf); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+
+	for _, line := range []string{
+		"# TYPE cosmos_requests_total counter",
+		`cosmos_requests_total{operation="GetDocument",status_code="429"} 2`,
+		`cosmos_request_charge_total{operation="CreateDocument"} 1`,
+		`cosmos_retries_total{operation="GetDocument"} 2`,
+		"cosmos_throttled_requests_total 2",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("expected exposition to contain %q, got:\n%s", line, buf.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected metrics endpoint response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
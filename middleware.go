@@ -0,0 +1,189 @@
+package cosmos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type Request struct {
+	Operation    string
+	Method       string
+	ResourceType string
+	ResourceLink string
+	Link         string
+	PartitionKey string
+	Header       http.Header
+	Body         []byte
+}
+
+type Response struct {
+	ResponseInfo
+
+	Header http.Header
+	Body   []byte
+
+	raw *http.Response
+}
+
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+type Middleware func(next Handler) Handler
+
+type operationKey struct{}
+
+func withOperation(ctx context.Context, operationName string) context.Context {
+	return context.WithValue(ctx, operationKey{}, strings.TrimPrefix(operationName, "cosmos."))
+}
+
+func operationFromContext(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+func newRequest(ctx context.Context, req *http.Request, link string, body []byte) *Request {
+	resourceType, resourceLink := resourceTypeFromLink(link)
+
+	return &Request{
+		Operation:    operationFromContext(ctx),
+		Method:       req.Method,
+		ResourceType: resourceType,
+		ResourceLink: resourceLink,
+		Link:         link,
+		PartitionKey: req.Header.Get(api.HEADER_PARTITION_KEY),
+		Header:       req.Header,
+		Body:         body,
+	}
+}
+
+func newResponse(res *http.Response) *Response {
+	if res == nil {
+		return nil
+	}
+
+	var body []byte
+	if res.Body != nil {
+		body, _ = ioutil.ReadAll(res.Body)
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return &Response{
+		ResponseInfo: newResponseInfo(res),
+		Header:       res.Header,
+		Body:         body,
+		raw:          res,
+	}
+}
+
+func (r *Response) httpResponse(req *http.Request) *http.Response {
+	if r == nil {
+		return nil
+	}
+
+	if r.raw != nil {
+		return r.raw
+	}
+
+	header := r.Header
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		StatusCode: r.StatusCode,
+		Status:     http.StatusText(r.StatusCode),
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(r.Body)),
+		Request:    req,
+	}
+}
+
+func (r *Response) decode(res *http.Response, out interface{}) error {
+	switch r.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusMultiStatus:
+		if len(r.Body) == 0 || out == nil {
+			return nil
+		}
+
+		return json.Unmarshal(r.Body, out)
+	case http.StatusNoContent, http.StatusNotModified:
+		return nil
+	}
+
+	if decoder, ok := out.(errorBodyDecoder); ok && decoder.decodeErrorBody(r.StatusCode) {
+		json.Unmarshal(r.Body, out) // nolint:errcheck
+	}
+
+	return errorFromResponse(res)
+}
+
+func (r *Request) httpRequest(ctx context.Context, endpoint *url.URL) (*http.Request, error) {
+	uri, _ := url.Parse(endpoint.String())
+	uri.Path = r.Link
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, uri.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.Header
+
+	return req, nil
+}
+
+func (c Client) handler(out interface{}) Handler {
+	var h Handler = func(ctx context.Context, r *Request) (*Response, error) {
+		read := isReadRequest(&http.Request{Method: r.Method, Header: r.Header})
+		tried := make(map[string]bool)
+		endpoints := c.endpointsFor(ctx, r.Link, read, tried)
+
+		for {
+			endpoint := endpoints[0]
+			endpoints = endpoints[1:]
+			tried[endpoint.Host] = true
+
+			req, err := r.httpRequest(ctx, endpoint)
+			if err != nil {
+				return nil, err
+			}
+
+			policy := c.retryPolicyFor(ctx)
+			if len(endpoints) > 0 {
+				policy = failoverRetryPolicy{policy}
+			}
+
+			res, err := doRequest(ctx, c, req, r.Body, out, policy)
+			if c.locations != nil && r.Link != "" {
+				if reason := failoverReasonFor(ctx, res, err); reason != noFailover {
+					c.locations.markUnavailable(endpoint, reason)
+					switch {
+					case reason != failoverUnreachable:
+						endpoints = c.endpointsFor(ctx, r.Link, read, tried)
+					case !read && !isUnsentRequestError(err):
+						endpoints = nil
+					}
+					if len(endpoints) > 0 {
+						continue
+					}
+				}
+			}
+
+			if res != nil {
+				c.captureSessionToken(req, res)
+			}
+
+			return newResponse(res), err
+		}
+	}
+
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+
+	return h
+}
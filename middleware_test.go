@@ -0,0 +1,191 @@
+package cosmos_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+)
+
+func TestMiddleware(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	var requests []cosmos.Request
+	var responses []cosmos.ResponseInfo
+
+	audit := func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			mu.Lock()
+			order = append(order, "audit")
+			requests = append(requests, *req)
+			mu.Unlock()
+
+			res, err := next(ctx, req)
+			if res != nil {
+				mu.Lock()
+				responses = append(responses, res.ResponseInfo)
+				mu.Unlock()
+			}
+			return res, err
+		}
+	}
+
+	tagging := func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			mu.Lock()
+			order = append(order, "tagging")
+			mu.Unlock()
+
+			req.Header.Set("x-custom-tenant", "contoso")
+			return next(ctx, req)
+		}
+	}
+
+	faults := func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			if req.Operation == "DeleteDocument" {
+				return &cosmos.Response{ResponseInfo: cosmos.ResponseInfo{StatusCode: http.StatusServiceUnavailable}}, &cosmos.CosmosError{Code: cosmos.ErrInternalServerError, Message: "injected fault"}
+			}
+			return next(ctx, req)
+		}
+	}
+
+	server, coll := newTestCollection(t, cosmos.WithMiddleware(audit, tagging), cosmos.WithMiddleware(faults))
+	ctx := context.Background()
+
+	mu.Lock()
+	order, requests, responses = nil, nil, nil
+	mu.Unlock()
+
+	doc := account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}
+	if err := coll.CreateDocument(ctx, "alice", doc, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	if got := server.LastRequestHeader("x-custom-tenant"); got != "contoso" {
+		t.Errorf("expected middleware header to reach the server, got %q", got)
+	}
+
+	mu.Lock()
+	if len(order) != 2 || order[0] != "audit" || order[1] != "tagging" {
+		t.Errorf("expected middleware to run in registration order, got %v", order)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected one request, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.Operation != "CreateDocument" || req.Method != http.MethodPost || req.ResourceType != "docs" ||
+		req.ResourceLink != "dbs/bank/colls/accounts" || req.PartitionKey != `["alice"]` || len(req.Body) == 0 {
+		t.Errorf("unexpected request %+v", req)
+	}
+	if len(responses) != 1 || responses[0].StatusCode != http.StatusCreated || responses[0].RequestCharge != 1 {
+		t.Errorf("unexpected responses %+v", responses)
+	}
+	mu.Unlock()
+
+	var info cosmos.ResponseInfo
+	err := coll.DeleteDocument(ctx, "alice", &doc, cosmos.WithResponseInfo(&info))
+	if !cosmos.IsInternalServerError(err) || info.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected injected fault, got %v and %+v", err, info)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Errorf("expected document to survive the injected fault: %v", err)
+	}
+}
+
+func TestMiddlewareShortCircuitsQuery(t *testing.T) {
+	cached := func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			if req.Operation == "QueryDocuments" {
+				return &cosmos.Response{ResponseInfo: cosmos.ResponseInfo{StatusCode: http.StatusOK}}, nil
+			}
+			return next(ctx, req)
+		}
+	}
+
+	_, coll := newTestCollection(t, cosmos.WithMiddleware(cached))
+
+	it, err := coll.QueryDocuments(context.Background(), "alice", "SELECT * FROM c")
+	if err != nil {
+		t.Fatalf("failed to query documents: %v", err)
+	}
+
+	var accounts []account
+	if err := it.All(&accounts); err != nil {
+		t.Fatalf("failed to read results: %v", err)
+	}
+	if len(accounts) != 0 {
+		t.Errorf("expected no documents from the short-circuited query, got %d", len(accounts))
+	}
+	if info := it.ResponseInfo(); info.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", info.StatusCode)
+	}
+}
+
+func TestMiddlewareResponseBody(t *testing.T) {
+	var mu sync.Mutex
+	cache := make(map[string][]byte)
+
+	cached := func(next cosmos.Handler) cosmos.Handler {
+		return func(ctx context.Context, req *cosmos.Request) (*cosmos.Response, error) {
+			if req.Method != http.MethodGet {
+				return next(ctx, req)
+			}
+
+			mu.Lock()
+			body, ok := cache[req.Link]
+			mu.Unlock()
+			if ok {
+				return &cosmos.Response{ResponseInfo: cosmos.ResponseInfo{StatusCode: http.StatusOK}, Body: body}, nil
+			}
+			if req.Link == "dbs/bank/colls/accounts/docs/missing" {
+				return &cosmos.Response{ResponseInfo: cosmos.ResponseInfo{StatusCode: http.StatusNotFound}}, nil
+			}
+
+			res, err := next(ctx, req)
+			if err == nil && res.StatusCode == http.StatusOK {
+				mu.Lock()
+				cache[req.Link] = res.Body
+				mu.Unlock()
+			}
+			return res, err
+		}
+	}
+
+	_, coll := newTestCollection(t, cosmos.WithMiddleware(cached))
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice", Balance: 42}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+
+	mu.Lock()
+	body := cache["dbs/bank/colls/accounts/docs/1"]
+	mu.Unlock()
+	if len(body) == 0 {
+		t.Fatal("expected middleware to see the response body")
+	}
+
+	acc.Balance = 50
+	if err := coll.ReplaceDocument(ctx, "alice", acc); err != nil {
+		t.Fatalf("failed to replace document: %v", err)
+	}
+
+	var cachedAcc account
+	if err := coll.GetDocument(ctx, "alice", "1", &cachedAcc); err != nil || cachedAcc.Balance != 42 {
+		t.Errorf("expected the short-circuited response body to be decoded, got %+v (%v)", cachedAcc, err)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "missing", &acc); !cosmos.IsNotFound(err) {
+		t.Errorf("expected a short-circuited 404 to be returned as an error, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package cosmos
+
+import (
+	"github.com/zhevron/cosmos/api"
+)
+
+type PatchOperation = api.PatchOperation
+
+func PatchAdd(path string, value interface{}) PatchOperation {
+	return PatchOperation{Op: api.PatchOperationAdd, Path: path, Value: value}
+}
+
+func PatchSet(path string, value interface{}) PatchOperation {
+	return PatchOperation{Op: api.PatchOperationSet, Path: path, Value: value}
+}
+
+func PatchReplace(path string, value interface{}) PatchOperation {
+	return PatchOperation{Op: api.PatchOperationReplace, Path: path, Value: value}
+}
+
+func PatchRemove(path string) PatchOperation {
+	return PatchOperation{Op: api.PatchOperationRemove, Path: path}
+}
+
+func PatchIncrement(path string, value interface{}) PatchOperation {
+	return PatchOperation{Op: api.PatchOperationIncrement, Path: path, Value: value}
+}
+
+func PatchMove(from string, path string) PatchOperation {
+	return PatchOperation{Op: api.PatchOperationMove, Path: path, From: from}
+}
@@ -256,6 +256,38 @@ func (e arrayNotContains) String() string {
 	return e.arrayContains.String() + " = false"
 }
 
+type field string
+
+func Field(name string) Expression {
+	return field(name)
+}
+
+func (e field) String() string {
+	return string(e)
+}
+
+type udf struct {
+	Name string
+	Args []interface{}
+}
+
+func UDF(name string, args ...interface{}) Expression {
+	return udf{Name: name, Args: args}
+}
+
+func (e udf) String() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		if expr, ok := arg.(Expression); ok {
+			args[i] = expr.String()
+		} else {
+			args[i] = valueToString(arg)
+		}
+	}
+
+	return "udf." + e.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
 func valueToString(value interface{}) string {
 	switch v := value.(type) {
 	case int:
@@ -0,0 +1,139 @@
+package cosmos
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const (
+	defaultChargeEstimate    = 1.0
+	chargeEstimateWeight     = 0.2
+	minRequestUnitsPerSecond = 1.0
+)
+
+type RateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	tokens    float64
+	updatedAt time.Time
+	estimates map[string]float64
+}
+
+func NewRateLimiter(requestUnitsPerSecond float64, burst float64) *RateLimiter {
+	if requestUnitsPerSecond < minRequestUnitsPerSecond {
+		requestUnitsPerSecond = minRequestUnitsPerSecond
+	}
+	if burst <= 0 {
+		burst = requestUnitsPerSecond
+	}
+
+	return &RateLimiter{
+		rate:      requestUnitsPerSecond,
+		burst:     burst,
+		tokens:    burst,
+		updatedAt: time.Now(),
+		estimates: make(map[string]float64),
+	}
+}
+
+func (l *RateLimiter) Available() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	return l.tokens
+}
+
+func (l *RateLimiter) Estimate(operation string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.estimate(operation)
+}
+
+func (l *RateLimiter) reserve(ctx context.Context, operation string) (float64, error) {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.refill(now)
+
+		estimate := l.estimate(operation)
+		required := estimate
+		if required > l.burst {
+			required = l.burst
+		}
+
+		if l.tokens >= required {
+			l.tokens -= estimate
+			l.mu.Unlock()
+			return estimate, nil
+		}
+
+		wait := time.Duration((required - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if err := waitForRetry(ctx, wait); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (l *RateLimiter) settle(operation string, reserved float64, res *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	charge := 0.0
+	if res != nil {
+		if c, err := strconv.ParseFloat(res.Header.Get(api.HEADER_REQUEST_CHARGE), 64); err == nil {
+			charge = c
+			if estimate, ok := l.estimates[operation]; ok {
+				l.estimates[operation] = estimate + chargeEstimateWeight*(charge-estimate)
+			} else {
+				l.estimates[operation] = charge
+			}
+		}
+	}
+
+	l.tokens += reserved - charge
+}
+
+func (l *RateLimiter) estimate(operation string) float64 {
+	if estimate, ok := l.estimates[operation]; ok && estimate > 0 {
+		return estimate
+	}
+
+	return defaultChargeEstimate
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.updatedAt).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.updatedAt = now
+}
+
+func (c Client) rateLimiterFor(req *http.Request) *RateLimiter {
+	if link, ok := collectionLinkFromPath(req.URL.Path); ok {
+		if limiter, ok := c.collectionLimiters[link]; ok {
+			return limiter
+		}
+	}
+
+	return c.limiter
+}
+
+func rateLimiterOperation(ctx context.Context, req *http.Request) string {
+	if operation := operationFromContext(ctx); operation != "" {
+		return operation
+	}
+
+	resourceType, _ := resourceTypeFromLink(req.URL.Path)
+	return req.Method + " " + resourceType
+}
@@ -0,0 +1,118 @@
+package cosmos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := cosmos.NewRateLimiter(100, 10)
+	server, coll := newTestCollection(t, cosmos.WithRateLimiter(limiter))
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	server.SetRequestCharge(5)
+
+	started := time.Now()
+	var acc account
+	for i := 0; i < 6; i++ {
+		if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+			t.Fatalf("failed to get document: %v", err)
+		}
+	}
+
+	if elapsed := time.Since(started); elapsed < 150*time.Millisecond {
+		t.Errorf("expected 30 RU at 100 RU/s with a burst of 10 to take at least 150ms, took %v", elapsed)
+	}
+
+	if estimate := limiter.Estimate("GetDocument"); estimate != 5 {
+		t.Errorf("expected the limiter to learn a charge of 5 RU, got %v", estimate)
+	}
+
+	server.SetRequestCharge(1000)
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+
+	if available := limiter.Available(); available > -900 {
+		t.Fatalf("expected the bucket to be in debt after an expensive request, got %v", available)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the limiter wait to respect the context, got %v", err)
+	}
+}
+
+func TestRateLimiterClampsRate(t *testing.T) {
+	for _, tt := range []struct {
+		rate  float64
+		burst float64
+	}{
+		{0, 0},
+		{-10, -5},
+	} {
+		limiter := cosmos.NewRateLimiter(tt.rate, tt.burst)
+		if available := limiter.Available(); available != 1 {
+			t.Errorf("expected NewRateLimiter(%v, %v) to clamp to 1 RU/s, got %v available", tt.rate, tt.burst, available)
+		}
+	}
+
+	server := cosmostest.NewServer()
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(cosmos.WithRateLimiter(cosmos.NewRateLimiter(0, 0)))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.CreateDatabase(ctx, "bank"); err != nil {
+		t.Errorf("expected a clamped limiter to admit requests, got %v", err)
+	}
+}
+
+func TestCollectionRateLimiter(t *testing.T) {
+	server := cosmostest.NewServer()
+	t.Cleanup(server.Close)
+
+	limiter := cosmos.NewRateLimiter(1, 1000)
+	client, err := server.Client(cosmos.WithCollectionRateLimiter("bank", "reports", limiter))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, _ := client.CreateDatabase(ctx, "bank")
+	accounts, _ := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	reports, _ := db.CreateCollection(ctx, "reports", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+
+	server.SetRequestCharge(100)
+
+	if err := accounts.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+	if available := limiter.Available(); available != 1000 {
+		t.Errorf("expected other collections not to debit the limiter, got %v", available)
+	}
+
+	if err := reports.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+	if available := limiter.Available(); available > 901 {
+		t.Errorf("expected the collection limiter to be debited 100 RU, got %v", available)
+	}
+}
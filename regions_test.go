@@ -0,0 +1,151 @@
+package cosmos_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func newTestRegions(t *testing.T, opts ...cosmos.DialOption) (*cosmostest.Region, *cosmostest.Region, *cosmos.Collection) {
+	t.Helper()
+
+	server := cosmostest.NewServer()
+	t.Cleanup(server.Close)
+
+	west := server.AddRegion("West US", true)
+	east := server.AddRegion("East US", false)
+
+	client, err := server.Client(opts...)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, err := client.CreateDatabase(ctx, "bank")
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	coll, err := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	return west, east, coll
+}
+
+func TestPreferredRegionRoutesReads(t *testing.T) {
+	west, east, coll := newTestRegions(t, cosmos.WithPreferredRegions("East US"))
+	ctx := context.Background()
+
+	if west.Requests() == 0 {
+		t.Fatalf("expected writes to go to the writable region")
+	}
+
+	writes, reads := west.Requests(), east.Requests()
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+
+	if east.Requests() != reads+1 {
+		t.Errorf("expected the read to go to the preferred region, got %d requests", east.Requests()-reads)
+	}
+	if west.Requests() != writes {
+		t.Errorf("expected no reads in the write region, got %d requests", west.Requests()-writes)
+	}
+}
+
+func TestReadFailoverOnAccountNotFound(t *testing.T) {
+	west, east, coll := newTestRegions(t, cosmos.WithPreferredRegions("East US"))
+	ctx := context.Background()
+
+	east.Fail(1, http.StatusForbidden, 1008)
+	writes := west.Requests()
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("expected the read to fail over, got %v", err)
+	}
+
+	if acc.Owner != "alice" {
+		t.Errorf("expected owner alice, got %q", acc.Owner)
+	}
+	if west.Requests() <= writes {
+		t.Errorf("expected the read to be served by the remaining region")
+	}
+}
+
+func TestReadFailoverOnConnectionError(t *testing.T) {
+	west, east, coll := newTestRegions(t, cosmos.WithPreferredRegions("East US"), cosmos.WithRetries(0))
+	ctx := context.Background()
+
+	east.Close()
+
+	var acc account
+	for i := 0; i < 2; i++ {
+		if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+			t.Fatalf("expected the read to fail over, got %v", err)
+		}
+	}
+
+	writes := west.Requests()
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if west.Requests() != writes+1 {
+		t.Errorf("expected the unreachable region to be skipped, got %d requests", west.Requests()-writes)
+	}
+}
+
+func TestWriteFailoverOnWriteForbidden(t *testing.T) {
+	west, east, coll := newTestRegions(t, cosmos.WithEndpointDiscovery(true))
+	ctx := context.Background()
+
+	west.SetWritable(false)
+	east.SetWritable(true)
+	writes := east.Requests()
+
+	if err := coll.CreateDocument(ctx, "bob", account{Document: cosmos.Document{ID: "2"}, Owner: "bob"}, false); err != nil {
+		t.Fatalf("expected the write to fail over, got %v", err)
+	}
+
+	if east.Requests() != writes+1 {
+		t.Errorf("expected the write to reach the new write region, got %d requests", east.Requests()-writes)
+	}
+}
+
+func TestLocalErrorsDoNotFailOver(t *testing.T) {
+	breaker := cosmos.NewCircuitBreaker(cosmos.BreakerFailureThreshold(1))
+	_, east, coll := newTestRegions(t, cosmos.WithPreferredRegions("East US"), cosmos.WithCircuitBreaker(breaker), cosmos.WithRetries(0))
+	ctx := context.Background()
+
+	east.Fail(1, http.StatusServiceUnavailable, 0)
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); !cosmos.IsInternalServerError(err) {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); !cosmos.IsCircuitOpen(err) {
+		t.Fatalf("expected the open circuit to be reported, got %v", err)
+	}
+
+	breaker.Reset()
+	reads := east.Requests()
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if east.Requests() != reads+1 {
+		t.Errorf("expected the preferred region to stay in rotation, got %d requests", east.Requests()-reads)
+	}
+}
@@ -0,0 +1,170 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type requestOptions struct {
+	headers         map[string]string
+	info            *ResponseInfo
+	timeToLive      *int
+	retryPolicy     RetryPolicy
+	ifMatchDocument bool
+}
+
+type RequestOption func(*requestOptions)
+
+func WithIndexingDirective(directive api.IndexingDirective) RequestOption {
+	return func(o *requestOptions) {
+		o.headers[api.HEADER_INDEXING_DIRECTIVE] = string(directive)
+	}
+}
+
+func WithTimeToLive(seconds int) RequestOption {
+	return func(o *requestOptions) {
+		o.timeToLive = &seconds
+	}
+}
+
+func WithIfMatch(etag string) RequestOption {
+	return func(o *requestOptions) {
+		if etag == "" {
+			delete(o.headers, api.HEADER_IF_MATCH)
+			return
+		}
+
+		o.headers[api.HEADER_IF_MATCH] = etag
+	}
+}
+
+func WithIfMatchDocumentEtag() RequestOption {
+	return func(o *requestOptions) {
+		o.ifMatchDocument = true
+	}
+}
+
+func WithContentResponseOnWrite(enabled bool) RequestOption {
+	return func(o *requestOptions) {
+		if enabled {
+			delete(o.headers, api.HEADER_PREFER)
+			return
+		}
+
+		o.headers[api.HEADER_PREFER] = "return=minimal"
+	}
+}
+
+func WithMaxItemCount(count int) RequestOption {
+	return func(o *requestOptions) {
+		o.headers[api.HEADER_MAX_ITEM_COUNT] = strconv.Itoa(count)
+	}
+}
+
+func WithRequestRetryPolicy(policy RetryPolicy) RequestOption {
+	return func(o *requestOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+func WithPreTriggers(triggers ...string) RequestOption {
+	return func(o *requestOptions) {
+		o.headers[api.HEADER_PRE_TRIGGER_INCLUDE] = strings.Join(triggers, ",")
+	}
+}
+
+func WithPostTriggers(triggers ...string) RequestOption {
+	return func(o *requestOptions) {
+		o.headers[api.HEADER_POST_TRIGGER_INCLUDE] = strings.Join(triggers, ",")
+	}
+}
+
+func WithSessionToken(token string) RequestOption {
+	return func(o *requestOptions) {
+		o.headers[api.HEADER_SESSION_TOKEN] = token
+	}
+}
+
+func WithReadConsistency(level api.ConsistencyLevel) RequestOption {
+	return func(o *requestOptions) {
+		o.headers[api.HEADER_CONSISTENCY_LEVEL] = string(level)
+	}
+}
+
+type requestHeadersKey struct{}
+
+func ContextWithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	headers := make(map[string]string)
+	if parent, ok := ctx.Value(requestHeadersKey{}).(map[string]string); ok {
+		for k, v := range parent {
+			headers[k] = v
+		}
+	}
+
+	ctx = applyRequestOptions(ctx, headers, opts)
+	return context.WithValue(ctx, requestHeadersKey{}, headers)
+}
+
+func requestHeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(requestHeadersKey{}).(map[string]string)
+	return headers
+}
+
+func applyRequestOptions(ctx context.Context, headers map[string]string, opts []RequestOption) context.Context {
+	options := &requestOptions{headers: headers}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.info != nil && ctx.Value(responseInfoKey{}) != options.info {
+		*options.info = ResponseInfo{}
+		ctx = context.WithValue(ctx, responseInfoKey{}, options.info)
+	}
+
+	if options.retryPolicy != nil {
+		ctx = context.WithValue(ctx, retryPolicyKey{}, options.retryPolicy)
+	}
+
+	return ctx
+}
+
+func documentIfMatch(document interface{}, opts []RequestOption) string {
+	options := &requestOptions{headers: make(map[string]string)}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if !options.ifMatchDocument {
+		return options.headers[api.HEADER_IF_MATCH]
+	}
+
+	return DocumentEtag(document)
+}
+
+func applyTimeToLive(document interface{}, opts []RequestOption) (interface{}, error) {
+	options := &requestOptions{headers: make(map[string]string)}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.timeToLive == nil {
+		return document, nil
+	}
+
+	b, err := serialize(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	fields["ttl"] = json.RawMessage(strconv.Itoa(*options.timeToLive))
+	return fields, nil
+}
@@ -0,0 +1,85 @@
+package cosmos_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+)
+
+func TestRequestOptions(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	var info cosmos.ResponseInfo
+	err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false,
+		cosmos.WithIndexingDirective(api.IndexingDirectiveExclude),
+		cosmos.WithTimeToLive(60),
+		cosmos.WithContentResponseOnWrite(false),
+		cosmos.WithResponseInfo(&info),
+	)
+	if err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	if sent := server.LastRequestHeader(api.HEADER_INDEXING_DIRECTIVE); sent != string(api.IndexingDirectiveExclude) {
+		t.Errorf("expected indexing directive Exclude, got %q", sent)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_PREFER); sent != "return=minimal" {
+		t.Errorf("expected minimal content response, got %q", sent)
+	}
+	if info.Etag == "" {
+		t.Errorf("expected minimal response to still carry an etag, got %+v", info)
+	}
+
+	var stored struct {
+		account
+		TTL int `json:"ttl"`
+	}
+	if err := coll.GetDocument(ctx, "alice", "1", &stored); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if stored.TTL != 60 || stored.Owner != "alice" {
+		t.Errorf("expected document with ttl 60, got %+v", stored)
+	}
+
+	stale := stored.account
+	stored.Balance = 10
+	if err := coll.ReplaceDocument(ctx, "alice", &stored.account, cosmos.WithIfMatch(`"stale"`)); !cosmos.IsConcurrency(err) {
+		t.Errorf("expected explicit If-Match to fail the replace, got %v", err)
+	}
+	if err := coll.ReplaceDocument(ctx, "alice", &stored.account, cosmos.WithIfMatch("")); err != nil {
+		t.Errorf("expected replace without If-Match to succeed, got %v", err)
+	}
+	if err := coll.DeleteDocument(ctx, "alice", &stale, cosmos.WithIfMatch("*")); err != nil {
+		t.Errorf("expected delete with wildcard If-Match to succeed, got %v", err)
+	}
+
+	for _, id := range []string{"2", "3", "4"} {
+		if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: id}, Owner: "alice"}, false); err != nil {
+			t.Fatalf("failed to create document: %v", err)
+		}
+	}
+
+	it, err := coll.QueryDocumentsWithOptions(ctx, "alice", "SELECT * FROM c", nil, cosmos.WithMaxItemCount(1), cosmos.WithResponseInfo(&info))
+	if err != nil {
+		t.Fatalf("failed to query documents: %v", err)
+	}
+
+	var results []account
+	if err := it.All(&results); err != nil {
+		t.Fatalf("failed to read query results: %v", err)
+	}
+	if len(results) != 3 || info.RequestCharge != 3 {
+		t.Errorf("expected 3 single-item pages, got %d results and %+v", len(results), info)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "2", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_MAX_ITEM_COUNT); sent == "1" {
+		t.Errorf("expected query options not to apply to later requests, got max item count %q", sent)
+	}
+}
@@ -0,0 +1,18 @@
+package cosmos
+
+import (
+	"strings"
+)
+
+func (c Client) resourceToken(link string) (string, bool) {
+	link = strings.Trim(link, "/")
+
+	var match, token string
+	for resource, t := range c.resourceTokens {
+		if (link == resource || strings.HasPrefix(link, resource+"/")) && len(resource) > len(match) {
+			match, token = resource, t
+		}
+	}
+
+	return token, token != ""
+}
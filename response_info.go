@@ -0,0 +1,71 @@
+package cosmos
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type ResponseInfo struct {
+	StatusCode     int
+	Substatus      int
+	RequestCharge  float64
+	ActivityID     string
+	SessionToken   string
+	ItemCount      int
+	ServerDuration time.Duration
+	ResourceQuota  string
+	ResourceUsage  string
+	Etag           string
+	Continuation   string
+}
+
+type responseInfoKey struct{}
+
+func WithResponseInfo(info *ResponseInfo) RequestOption {
+	return func(o *requestOptions) {
+		o.info = info
+	}
+}
+
+func newResponseInfo(res *http.Response) ResponseInfo {
+	info := ResponseInfo{
+		StatusCode:    res.StatusCode,
+		ActivityID:    res.Header.Get(api.HEADER_ACTIVITY_ID),
+		SessionToken:  res.Header.Get(api.HEADER_SESSION_TOKEN),
+		ResourceQuota: res.Header.Get(api.HEADER_RESOURCE_QUOTA),
+		ResourceUsage: res.Header.Get(api.HEADER_RESOURCE_USAGE),
+		Etag:          res.Header.Get(api.HEADER_ETAG),
+		Continuation:  res.Header.Get(api.HEADER_CONTINUATION),
+	}
+
+	info.Substatus, _ = strconv.Atoi(res.Header.Get(api.HEADER_SUBSTATUS))
+	info.RequestCharge, _ = strconv.ParseFloat(res.Header.Get(api.HEADER_REQUEST_CHARGE), 64)
+	info.ItemCount, _ = strconv.Atoi(res.Header.Get(api.HEADER_ITEM_COUNT))
+
+	if duration, err := strconv.ParseFloat(res.Header.Get(api.HEADER_REQUEST_DURATION), 64); err == nil {
+		info.ServerDuration = time.Duration(duration * float64(time.Millisecond))
+	}
+
+	return info
+}
+
+func (i *ResponseInfo) add(other ResponseInfo) {
+	charge := i.RequestCharge + other.RequestCharge
+	itemCount := i.ItemCount + other.ItemCount
+	duration := i.ServerDuration + other.ServerDuration
+
+	*i = other
+	i.RequestCharge = charge
+	i.ItemCount = itemCount
+	i.ServerDuration = duration
+}
+
+func recordResponseInfo(ctx context.Context, res *http.Response) {
+	if info, ok := ctx.Value(responseInfoKey{}).(*ResponseInfo); ok && info != nil {
+		info.add(newResponseInfo(res))
+	}
+}
@@ -0,0 +1,147 @@
+package cosmos_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func TestResponseInfo(t *testing.T) {
+	server := cosmostest.NewServer(cosmostest.WithPageSize(2))
+	t.Cleanup(server.Close)
+
+	client, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	db, _ := client.CreateDatabase(ctx, "bank")
+	coll, err := db.CreateCollection(ctx, "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	var info cosmos.ResponseInfo
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false, cosmos.WithResponseInfo(&info)); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	if info.StatusCode != http.StatusCreated || info.RequestCharge != 1 || info.ActivityID == "" || info.Etag == "" {
+		t.Errorf("unexpected create response info %+v", info)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc, cosmos.WithResponseInfo(&info)); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if info.StatusCode != http.StatusOK || info.RequestCharge != 1 || info.Etag != acc.Etag {
+		t.Errorf("unexpected get response info %+v", info)
+	}
+
+	err = coll.UpdateDocument(ctx, "alice", "1", &acc, func() error {
+		acc.Balance++
+		return nil
+	}, cosmos.WithResponseInfo(&info))
+	if err != nil {
+		t.Fatalf("failed to update document: %v", err)
+	}
+	if info.RequestCharge != 2 {
+		t.Errorf("expected update to accumulate the charge of the read and the replace, got %v", info.RequestCharge)
+	}
+
+	for i := 2; i <= 5; i++ {
+		if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: strconv.Itoa(i)}, Owner: "alice"}, false); err != nil {
+			t.Fatalf("failed to create document: %v", err)
+		}
+	}
+
+	it, err := coll.QueryDocuments(ctx, "alice", "SELECT * FROM c")
+	if err != nil {
+		t.Fatalf("failed to query documents: %v", err)
+	}
+
+	var results []account
+	if err := it.All(&results); err != nil {
+		t.Fatalf("failed to read query results: %v", err)
+	}
+
+	pageInfo := it.ResponseInfo()
+	if len(results) != 5 || pageInfo.ItemCount != 5 || pageInfo.RequestCharge != 3 {
+		t.Errorf("expected 3 pages with 5 items in total, got %d results and %+v", len(results), pageInfo)
+	}
+}
+
+func TestResponseInfoFromContext(t *testing.T) {
+	server := cosmostest.NewServer(cosmostest.WithConsistencyLevel(api.ConsistencyLevelStrong))
+	t.Cleanup(server.Close)
+
+	client, err := server.Client(cosmos.WithConsistencyLevel(api.ConsistencyLevelSession))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	var info cosmos.ResponseInfo
+	withInfo := func() context.Context {
+		return cosmos.ContextWithRequestOptions(context.Background(), cosmos.WithResponseInfo(&info))
+	}
+
+	db, err := client.CreateDatabase(withInfo(), "bank")
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	if info.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected create database response info %+v", info)
+	}
+
+	coll, err := db.CreateCollection(context.Background(), "accounts", cosmos.WithPartitionKey(api.PartitionKey{Paths: []string{"/owner"}}))
+	if err != nil {
+		t.Fatalf("failed to create collection: %v", err)
+	}
+
+	if _, err := db.ListCollections(withInfo()); err != nil || info.StatusCode != http.StatusOK || info.RequestCharge != 1 || info.ActivityID == "" {
+		t.Errorf("unexpected list collections response info %+v (%v)", info, err)
+	}
+
+	if err := coll.CreateDocument(context.Background(), "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var patched account
+	if err := coll.PatchDocument(withInfo(), "alice", "1", &patched, cosmos.PatchSet("/balance", 5)); err != nil {
+		t.Fatalf("failed to patch document: %v", err)
+	}
+	if info.StatusCode != http.StatusOK || info.RequestCharge != 1 || info.Etag != patched.Etag {
+		t.Errorf("unexpected patch response info %+v", info)
+	}
+
+	if _, err := coll.CreateStoredProcedure(withInfo(), "deposit", "function deposit() {}"); err != nil || info.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected create stored procedure response info %+v (%v)", info, err)
+	}
+
+	if _, err := coll.CreateTrigger(withInfo(), "audit", api.TriggerTypePost, api.TriggerOperationAll, "function() {}"); err != nil || info.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected create trigger response info %+v (%v)", info, err)
+	}
+
+	if _, err := coll.CreateUserDefinedFunction(withInfo(), "tax", "function tax(x) { return x; }"); err != nil || info.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected create user defined function response info %+v (%v)", info, err)
+	}
+
+	user, err := db.CreateUser(withInfo(), "reader")
+	if err != nil || info.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected create user response info %+v (%v)", info, err)
+	}
+
+	if _, err := user.CreatePermission(withInfo(), "accounts", api.PermissionModeRead, "dbs/bank/colls/accounts"); err != nil || info.StatusCode != http.StatusCreated {
+		t.Errorf("unexpected create permission response info %+v (%v)", info, err)
+	}
+
+	if err := client.DeleteDatabase(withInfo(), "bank"); err != nil || info.StatusCode != http.StatusNoContent {
+		t.Errorf("unexpected delete database response info %+v (%v)", info, err)
+	}
+}
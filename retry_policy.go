@@ -0,0 +1,182 @@
+package cosmos
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+const (
+	substatusPartitionKeyRangeGone        = 1002
+	substatusCompletingSplit              = 1007
+	substatusCompletingPartitionMigration = 1008
+	defaultMaxBackoff                     = 5 * time.Second
+	defaultMaxTotalWait                   = 30 * time.Second
+	defaultRetryJitter                    = 0.2
+)
+
+type RetryAttempt struct {
+	Attempt  int
+	Waited   time.Duration
+	Request  *http.Request
+	Response *http.Response
+	Err      error
+}
+
+type RetryPolicy interface {
+	ShouldRetry(attempt RetryAttempt) (time.Duration, bool)
+}
+
+type DefaultRetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxTotalWait   time.Duration
+	Jitter         float64
+	RetryOnStatus  []int
+}
+
+func NewDefaultRetryPolicy() *DefaultRetryPolicy {
+	return &DefaultRetryPolicy{
+		MaxRetries:     5,
+		InitialBackoff: defaultRetryAfter,
+		MaxBackoff:     defaultMaxBackoff,
+		MaxTotalWait:   defaultMaxTotalWait,
+		Jitter:         defaultRetryJitter,
+	}
+}
+
+func (p *DefaultRetryPolicy) ShouldRetry(attempt RetryAttempt) (time.Duration, bool) {
+	if attempt.Attempt >= p.MaxRetries {
+		return 0, false
+	}
+
+	var delay time.Duration
+	switch {
+	case attempt.Err != nil:
+		if attempt.Request != nil && !isReadRequest(attempt.Request) && !isUnsentRequestError(attempt.Err) {
+			return 0, false
+		}
+		delay = p.backoff(attempt.Attempt)
+
+	case attempt.Response != nil:
+		res := attempt.Response
+		if retryAfter, ok := retryAfterHeader(res.Header); ok {
+			delay = retryAfter
+		} else if p.retryable(res) {
+			delay = p.backoff(attempt.Attempt)
+		} else {
+			return 0, false
+		}
+
+	default:
+		return 0, false
+	}
+
+	if p.MaxTotalWait > 0 && attempt.Waited+delay > p.MaxTotalWait {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+func (p *DefaultRetryPolicy) retryable(res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, httpRetryAfter, http.StatusServiceUnavailable:
+		return true
+
+	case http.StatusGone:
+		switch substatus, _ := strconv.Atoi(res.Header.Get(api.HEADER_SUBSTATUS)); substatus {
+		case substatusPartitionKeyRangeGone, substatusCompletingSplit, substatusCompletingPartitionMigration:
+			return false
+		}
+		return true
+	}
+
+	for _, code := range p.RetryOnStatus {
+		if res.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *DefaultRetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 0; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay)) // nolint:gosec
+	}
+
+	return delay
+}
+
+func retryAfterHeader(header http.Header) (time.Duration, bool) {
+	for _, name := range []string{api.HEADER_RETRY_AFTER_MS, api.HEADER_RETRY_AFTER} {
+		if ms, err := strconv.Atoi(header.Get(name)); err == nil {
+			return time.Duration(ms) * time.Millisecond, true
+		}
+	}
+
+	return 0, false
+}
+
+func isUnsentRequestError(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+
+	return (errors.As(err, &opErr) && opErr.Op == "dial") || errors.As(err, &dnsErr)
+}
+
+type noRetryPolicy struct{}
+
+func (noRetryPolicy) ShouldRetry(RetryAttempt) (time.Duration, bool) {
+	return 0, false
+}
+
+type retryPolicyKey struct{}
+
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, noRetryPolicy{})
+}
+
+func (c Client) retryPolicyFor(ctx context.Context) RetryPolicy {
+	if policy, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return policy
+	}
+
+	if c.retryPolicy != nil {
+		return c.retryPolicy
+	}
+
+	policy := NewDefaultRetryPolicy()
+	policy.MaxRetries = c.MaxRetries
+	policy.RetryOnStatus = c.retryOnStatus
+	return policy
+}
+
+func waitForRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
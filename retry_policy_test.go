@@ -0,0 +1,195 @@
+package cosmos_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+)
+
+type recordingTransport struct {
+	mu       sync.Mutex
+	failures int
+	requests []http.Header
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, req.Header.Clone())
+	fail := t.failures > 0
+	if fail {
+		t.failures--
+	}
+	t.mu.Unlock()
+
+	if fail {
+		return nil, errors.New("connection reset by peer")
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+type fixedRetryPolicy struct {
+	delay   time.Duration
+	retries int
+}
+
+func (p fixedRetryPolicy) ShouldRetry(attempt cosmos.RetryAttempt) (time.Duration, bool) {
+	return p.delay, attempt.Attempt < p.retries
+}
+
+func TestRetryPolicy(t *testing.T) {
+	transport := &recordingTransport{}
+	policy := cosmos.NewDefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+
+	server, coll := newTestCollection(t, cosmos.WithTransport(transport), cosmos.WithRetryPolicy(policy))
+	ctx := context.Background()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	transport.mu.Lock()
+	transport.requests = nil
+	transport.mu.Unlock()
+
+	server.Fail(2, http.StatusServiceUnavailable)
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("expected 503 to be retried, got %v", err)
+	}
+
+	transport.mu.Lock()
+	attempts := transport.requests
+	transport.mu.Unlock()
+
+	if len(attempts) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(attempts))
+	}
+	for i, headers := range attempts {
+		if n := len(headers.Values(api.HEADER_AUTHORIZATION)); n != 1 || headers.Get(api.HEADER_DATE) == "" {
+			t.Errorf("expected attempt %d to be signed exactly once, got %d authorization headers", i, n)
+		}
+	}
+
+	transport.mu.Lock()
+	transport.failures = 2
+	transport.mu.Unlock()
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Errorf("expected network errors to be retried, got %v", err)
+	}
+
+	transport.mu.Lock()
+	transport.failures = 1
+	transport.requests = nil
+	transport.mu.Unlock()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "2"}, Owner: "alice"}, false); err == nil {
+		t.Error("expected a write that may have been sent not to be retried")
+	}
+
+	transport.mu.Lock()
+	attempts = transport.requests
+	transport.mu.Unlock()
+
+	if len(attempts) != 1 {
+		t.Errorf("expected a single write attempt, got %d", len(attempts))
+	}
+
+	server.Fail(1, http.StatusServiceUnavailable)
+	if err := coll.GetDocument(ctx, "alice", "1", &acc, cosmos.WithRequestRetryPolicy(fixedRetryPolicy{})); !cosmos.IsInternalServerError(err) {
+		t.Errorf("expected per-operation policy to disable retries, got %v", err)
+	}
+
+	server.Fail(1, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := coll.GetDocument(ctx, "alice", "1", &acc, cosmos.WithRequestRetryPolicy(fixedRetryPolicy{delay: time.Hour, retries: 1}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to be interrupted by the context, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("expected the wait to stop with the context, took %v", elapsed)
+	}
+}
+
+func TestRetryPolicyServerRetryAfter(t *testing.T) {
+	policy := cosmos.NewDefaultRetryPolicy()
+	policy.InitialBackoff = time.Minute
+	policy.Jitter = 0
+
+	server, coll := newTestCollection(t, cosmos.WithRetryPolicy(policy))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	server.Throttle(1)
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Errorf("expected the server's %s to be used as the retry delay, got %v", api.HEADER_RETRY_AFTER_MS, err)
+	}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	policy := cosmos.NewDefaultRetryPolicy()
+	policy.Jitter = 0
+
+	read, _ := http.NewRequest(http.MethodGet, "https://localhost/dbs/bank", nil)
+	write, _ := http.NewRequest(http.MethodPost, "https://localhost/dbs/bank/colls/accounts/docs", nil)
+
+	response := func(status int, headers map[string]string) *http.Response {
+		res := &http.Response{StatusCode: status, Header: http.Header{}}
+		for k, v := range headers {
+			res.Header.Set(k, v)
+		}
+		return res
+	}
+
+	tests := []struct {
+		name    string
+		attempt cosmos.RetryAttempt
+		delay   time.Duration
+		retry   bool
+	}{
+		{"throttled", cosmos.RetryAttempt{Response: response(http.StatusTooManyRequests, nil)}, 100 * time.Millisecond, true},
+		{"retry after", cosmos.RetryAttempt{Response: response(http.StatusTooManyRequests, map[string]string{api.HEADER_RETRY_AFTER: "250"})}, 250 * time.Millisecond, true},
+		{"ms retry after", cosmos.RetryAttempt{Response: response(http.StatusTooManyRequests, map[string]string{api.HEADER_RETRY_AFTER_MS: "5000"})}, 5000 * time.Millisecond, true},
+		{"ms retry after preferred", cosmos.RetryAttempt{Response: response(http.StatusTooManyRequests, map[string]string{api.HEADER_RETRY_AFTER_MS: "300", api.HEADER_RETRY_AFTER: "250"})}, 300 * time.Millisecond, true},
+		{"backoff", cosmos.RetryAttempt{Attempt: 3, Response: response(http.StatusServiceUnavailable, nil)}, 800 * time.Millisecond, true},
+		{"backoff grows", cosmos.RetryAttempt{Attempt: 4, Response: response(http.StatusServiceUnavailable, nil)}, 1600 * time.Millisecond, true},
+		{"gone", cosmos.RetryAttempt{Response: response(http.StatusGone, nil)}, 100 * time.Millisecond, true},
+		{"partition gone", cosmos.RetryAttempt{Response: response(http.StatusGone, map[string]string{api.HEADER_SUBSTATUS: "1002"})}, 0, false},
+		{"network error", cosmos.RetryAttempt{Attempt: 1, Err: errors.New("connection refused")}, 200 * time.Millisecond, true},
+		{"read network error", cosmos.RetryAttempt{Request: read, Err: errors.New("connection reset by peer")}, 100 * time.Millisecond, true},
+		{"write network error", cosmos.RetryAttempt{Request: write, Err: errors.New("connection reset by peer")}, 0, false},
+		{"write dial error", cosmos.RetryAttempt{Request: write, Err: &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}}, 100 * time.Millisecond, true},
+		{"not found", cosmos.RetryAttempt{Response: response(http.StatusNotFound, nil)}, 0, false},
+		{"max retries", cosmos.RetryAttempt{Attempt: 5, Response: response(http.StatusServiceUnavailable, nil)}, 0, false},
+		{"max total wait", cosmos.RetryAttempt{Waited: 29950 * time.Millisecond, Response: response(http.StatusServiceUnavailable, nil)}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := policy.ShouldRetry(tt.attempt)
+			if retry != tt.retry || delay != tt.delay {
+				t.Errorf("expected (%v, %v), got (%v, %v)", tt.delay, tt.retry, delay, retry)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package cosmos
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type sessionContainer struct {
+	mu     sync.RWMutex
+	tokens map[string]map[string]string
+}
+
+func newSessionContainer() *sessionContainer {
+	return &sessionContainer{
+		tokens: make(map[string]map[string]string),
+	}
+}
+
+func (s *sessionContainer) get(collectionLink string) string {
+	if s == nil {
+		return ""
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ranges := s.tokens[collectionLink]
+	if len(ranges) == 0 {
+		return ""
+	}
+
+	ids := make([]string, 0, len(ranges))
+	for id := range ranges {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	tokens := make([]string, len(ids))
+	for i, id := range ids {
+		tokens[i] = id + ":" + ranges[id]
+	}
+
+	return strings.Join(tokens, ",")
+}
+
+func (s *sessionContainer) set(collectionLink string, token string) {
+	if s == nil || token == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ranges, ok := s.tokens[collectionLink]
+	if !ok {
+		ranges = make(map[string]string)
+		s.tokens[collectionLink] = ranges
+	}
+
+	for _, part := range strings.Split(token, ",") {
+		i := strings.Index(part, ":")
+		if i <= 0 {
+			continue
+		}
+
+		id, value := strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		if existing, ok := ranges[id]; !ok || sessionTokenNewer(value, existing) {
+			ranges[id] = value
+		}
+	}
+}
+
+func (s *sessionContainer) clear(collectionLink string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, collectionLink)
+}
+
+func sessionTokenNewer(token string, existing string) bool {
+	version, lsn := parseSessionToken(token)
+	existingVersion, existingLSN := parseSessionToken(existing)
+
+	if version != existingVersion {
+		return version > existingVersion
+	}
+
+	return lsn > existingLSN
+}
+
+func parseSessionToken(token string) (int64, int64) {
+	parts := strings.Split(token, "#")
+	if len(parts) == 1 {
+		lsn, _ := strconv.ParseInt(parts[0], 10, 64)
+		return -1, lsn
+	}
+
+	version, _ := strconv.ParseInt(parts[0], 10, 64)
+	lsn, _ := strconv.ParseInt(parts[1], 10, 64)
+	return version, lsn
+}
+
+func collectionLinkFromPath(link string) (string, bool) {
+	parts := strings.Split(strings.Trim(link, "/"), "/")
+	if len(parts) < 4 || parts[0] != "dbs" || parts[2] != "colls" {
+		return "", false
+	}
+
+	return strings.Join(parts[:4], "/"), true
+}
+
+func isReadRequest(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		(req.Method == http.MethodPost && strings.EqualFold(req.Header.Get(api.HEADER_IS_QUERY), "True"))
+}
+
+func (c Client) applySessionToken(req *http.Request) {
+	if req.Header.Get(api.HEADER_SESSION_TOKEN) != "" || !isReadRequest(req) {
+		return
+	}
+
+	if link, ok := collectionLinkFromPath(req.URL.Path); ok {
+		if token := c.sessions.get(link); token != "" {
+			req.Header.Set(api.HEADER_SESSION_TOKEN, token)
+		}
+	}
+}
+
+func (c Client) captureSessionToken(req *http.Request, res *http.Response) {
+	token := res.Header.Get(api.HEADER_SESSION_TOKEN)
+	if token == "" {
+		return
+	}
+
+	if link, ok := collectionLinkFromPath(req.URL.Path); ok {
+		c.sessions.set(link, token)
+	}
+}
+
+func (c Collection) SessionToken() string {
+	return c.database.Client().sessions.get(createCollectionLink(c.database.ID, c.ID))
+}
+
+func (c Collection) SetSessionToken(token string) {
+	c.database.Client().sessions.set(createCollectionLink(c.database.ID, c.ID), token)
+}
+
+func (c Collection) ResetSessionToken() {
+	c.database.Client().sessions.clear(createCollectionLink(c.database.ID, c.ID))
+}
@@ -0,0 +1,89 @@
+package cosmos_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+)
+
+func TestSessionTokens(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	if token := coll.SessionToken(); token != "" {
+		t.Fatalf("expected no session token before any writes, got %q", token)
+	}
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	first := coll.SessionToken()
+	if first == "" {
+		t.Fatal("expected a session token after a write")
+	}
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "2"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	token := coll.SessionToken()
+	if token == first {
+		t.Errorf("expected the session token to advance after a second write, got %q", token)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_SESSION_TOKEN); sent != token {
+		t.Errorf("expected read to send session token %q, got %q", token, sent)
+	}
+
+	if _, err := coll.QueryDocuments(ctx, "alice", "SELECT * FROM c"); err != nil {
+		t.Fatalf("failed to query documents: %v", err)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_SESSION_TOKEN); sent != token {
+		t.Errorf("expected query to send session token %q, got %q", token, sent)
+	}
+
+	coll.SetSessionToken(first)
+	if merged := coll.SessionToken(); merged != token {
+		t.Errorf("expected importing an older token to keep %q, got %q", token, merged)
+	}
+
+	if err := coll.GetDocument(ctx, "alice", "1", &acc, cosmos.WithSessionToken("0:-1#1")); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if sent := server.LastRequestHeader(api.HEADER_SESSION_TOKEN); sent != "0:-1#1" {
+		t.Errorf("expected explicit session token to be sent, got %q", sent)
+	}
+
+	other, err := server.Client()
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	db, err := other.GetDatabase(ctx, "bank")
+	if err != nil {
+		t.Fatalf("failed to get database: %v", err)
+	}
+
+	otherColl, err := db.GetCollection(ctx, "accounts")
+	if err != nil {
+		t.Fatalf("failed to get collection: %v", err)
+	}
+
+	otherColl.SetSessionToken(token + ",9:-1#3")
+	if imported := otherColl.SessionToken(); !strings.HasPrefix(imported, token) || !strings.HasSuffix(imported, ",9:-1#3") {
+		t.Errorf("expected imported token to contain both ranges, got %q", imported)
+	}
+
+	otherColl.ResetSessionToken()
+	if reset := otherColl.SessionToken(); reset != "" {
+		t.Errorf("expected session token to be cleared, got %q", reset)
+	}
+}
@@ -0,0 +1,120 @@
+package cosmos
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type StoredProcedure struct {
+	api.StoredProcedure
+}
+
+func (c Collection) ListStoredProcedures(ctx context.Context) ([]*StoredProcedure, error) {
+	span, ctx := c.startCollectionSpan(ctx, "cosmos.ListStoredProcedures")
+	defer span.Finish()
+
+	var res api.ListStoredProceduresResponse
+	if _, err := c.database.Client().get(ctx, createStoredProcedureLink(c.database.ID, c.ID, ""), &res, nil); err != nil {
+		return nil, err
+	}
+
+	storedProcedures := make([]*StoredProcedure, len(res.StoredProcedures))
+	for i, sp := range res.StoredProcedures {
+		storedProcedures[i] = &StoredProcedure{
+			StoredProcedure: sp,
+		}
+	}
+
+	return storedProcedures, nil
+}
+
+func (c Collection) GetStoredProcedure(ctx context.Context, id string) (*StoredProcedure, error) {
+	span, ctx := c.startStoredProcedureSpan(ctx, "cosmos.GetStoredProcedure", id)
+	defer span.Finish()
+
+	var storedProcedure api.StoredProcedure
+	if _, err := c.database.Client().get(ctx, createStoredProcedureLink(c.database.ID, c.ID, id), &storedProcedure, nil); err != nil {
+		return nil, err
+	}
+
+	return &StoredProcedure{
+		StoredProcedure: storedProcedure,
+	}, nil
+}
+
+func (c Collection) CreateStoredProcedure(ctx context.Context, id string, body string) (*StoredProcedure, error) {
+	span, ctx := c.startStoredProcedureSpan(ctx, "cosmos.CreateStoredProcedure", id)
+	defer span.Finish()
+
+	storedProcedure := api.StoredProcedure{
+		ID:   id,
+		Body: body,
+	}
+
+	if _, err := c.database.Client().post(ctx, createStoredProcedureLink(c.database.ID, c.ID, ""), storedProcedure, &storedProcedure, nil); err != nil {
+		return nil, err
+	}
+
+	return &StoredProcedure{
+		StoredProcedure: storedProcedure,
+	}, nil
+}
+
+func (c Collection) ReplaceStoredProcedure(ctx context.Context, id string, body string) (*StoredProcedure, error) {
+	span, ctx := c.startStoredProcedureSpan(ctx, "cosmos.ReplaceStoredProcedure", id)
+	defer span.Finish()
+
+	storedProcedure := api.StoredProcedure{
+		ID:   id,
+		Body: body,
+	}
+
+	if _, err := c.database.Client().put(ctx, createStoredProcedureLink(c.database.ID, c.ID, id), storedProcedure, &storedProcedure, nil); err != nil {
+		return nil, err
+	}
+
+	return &StoredProcedure{
+		StoredProcedure: storedProcedure,
+	}, nil
+}
+
+func (c Collection) DeleteStoredProcedure(ctx context.Context, id string) error {
+	span, ctx := c.startStoredProcedureSpan(ctx, "cosmos.DeleteStoredProcedure", id)
+	defer span.Finish()
+
+	_, err := c.database.Client().delete(ctx, createStoredProcedureLink(c.database.ID, c.ID, id), nil)
+	return err
+}
+
+func (c Collection) ExecuteStoredProcedure(ctx context.Context, partitionKey interface{}, id string, out interface{}, args ...interface{}) (string, error) {
+	span, ctx := c.startStoredProcedureSpan(ctx, "cosmos.ExecuteStoredProcedure", id)
+	defer span.Finish()
+
+	headers := map[string]string{
+		api.HEADER_PARTITION_KEY:  makePartitionKeyHeaderValue(partitionKey),
+		api.HEADER_SCRIPT_LOGGING: "true",
+	}
+
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	res, err := c.database.Client().post(ctx, createStoredProcedureLink(c.database.ID, c.ID, id), args, out, headers)
+	if res == nil {
+		return "", err
+	}
+
+	scriptLog, _ := url.QueryUnescape(res.Header.Get(api.HEADER_SCRIPT_LOG_RESULTS))
+	return scriptLog, err
+}
+
+func (c Collection) startStoredProcedureSpan(ctx context.Context, operationName string, storedProcedureID string) (opentracing.Span, context.Context) {
+	span, ctx := c.startCollectionSpan(ctx, operationName)
+	span.SetTag("cosmos.stored_procedure", storedProcedureID)
+
+	return span, ctx
+}
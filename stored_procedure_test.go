@@ -0,0 +1,103 @@
+package cosmos_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/cosmostest"
+)
+
+func TestStoredProcedures(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	server.RegisterStoredProcedure("deposit", func(sc *cosmostest.ScriptContext, args []json.RawMessage) (interface{}, error) {
+		var id string
+		var amount float64
+		if err := json.Unmarshal(args[0], &id); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(args[1], &amount); err != nil {
+			return nil, err
+		}
+
+		doc, ok := sc.ReadDocument(id)
+		if !ok {
+			return nil, errors.New("account not found")
+		}
+
+		sc.Log("depositing %v into %s", amount, id)
+		doc["balance"] = doc["balance"].(float64) + amount
+		if amount < 0 {
+			return nil, errors.New("negative deposit")
+		}
+
+		return doc["balance"], sc.UpsertDocument(doc)
+	})
+
+	if _, err := coll.CreateStoredProcedure(ctx, "deposit", "function deposit(id, amount) {}"); err != nil {
+		t.Fatalf("failed to create stored procedure: %v", err)
+	}
+
+	if _, err := coll.CreateStoredProcedure(ctx, "deposit", "function deposit(id, amount) {}"); !cosmos.IsConflict(err) {
+		t.Errorf("expected conflict when creating duplicate stored procedure, got %v", err)
+	}
+
+	replaced, err := coll.ReplaceStoredProcedure(ctx, "deposit", "function deposit(id, amount) { /* v2 */ }")
+	if err != nil {
+		t.Fatalf("failed to replace stored procedure: %v", err)
+	}
+	if replaced.Etag == "" {
+		t.Errorf("expected replaced stored procedure to have an etag")
+	}
+
+	storedProcedures, err := coll.ListStoredProcedures(ctx)
+	if err != nil {
+		t.Fatalf("failed to list stored procedures: %v", err)
+	}
+	if len(storedProcedures) != 1 || storedProcedures[0].Body != "function deposit(id, amount) { /* v2 */ }" {
+		t.Errorf("unexpected stored procedures: %+v", storedProcedures)
+	}
+
+	if err := coll.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice", Balance: 5}, false); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var balance int
+	scriptLog, err := coll.ExecuteStoredProcedure(ctx, "alice", "deposit", &balance, "1", 10)
+	if err != nil {
+		t.Fatalf("failed to execute stored procedure: %v", err)
+	}
+	if balance != 15 {
+		t.Errorf("expected balance 15, got %d", balance)
+	}
+	if scriptLog != "depositing 10 into 1" {
+		t.Errorf("unexpected script log %q", scriptLog)
+	}
+
+	scriptLog, err = coll.ExecuteStoredProcedure(ctx, "alice", "deposit", nil, "1", -20)
+	if !cosmos.IsBadRequest(err) {
+		t.Errorf("expected bad request from failing stored procedure, got %v", err)
+	}
+	if scriptLog != "depositing -20 into 1" {
+		t.Errorf("expected script log to be returned on failure, got %q", scriptLog)
+	}
+
+	var acc account
+	if err := coll.GetDocument(ctx, "alice", "1", &acc); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if acc.Balance != 15 {
+		t.Errorf("expected failed stored procedure to be rolled back, balance is %d", acc.Balance)
+	}
+
+	if err := coll.DeleteStoredProcedure(ctx, "deposit"); err != nil {
+		t.Fatalf("failed to delete stored procedure: %v", err)
+	}
+	if _, err := coll.GetStoredProcedure(ctx, "deposit"); !cosmos.IsNotFound(err) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+}
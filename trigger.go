@@ -0,0 +1,101 @@
+package cosmos
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type Trigger struct {
+	api.Trigger
+}
+
+func (c Collection) ListTriggers(ctx context.Context) ([]*Trigger, error) {
+	span, ctx := c.startCollectionSpan(ctx, "cosmos.ListTriggers")
+	defer span.Finish()
+
+	var res api.ListTriggersResponse
+	if _, err := c.database.Client().get(ctx, createTriggerLink(c.database.ID, c.ID, ""), &res, nil); err != nil {
+		return nil, err
+	}
+
+	triggers := make([]*Trigger, len(res.Triggers))
+	for i, t := range res.Triggers {
+		triggers[i] = &Trigger{
+			Trigger: t,
+		}
+	}
+
+	return triggers, nil
+}
+
+func (c Collection) GetTrigger(ctx context.Context, id string) (*Trigger, error) {
+	span, ctx := c.startTriggerSpan(ctx, "cosmos.GetTrigger", id)
+	defer span.Finish()
+
+	var trigger api.Trigger
+	if _, err := c.database.Client().get(ctx, createTriggerLink(c.database.ID, c.ID, id), &trigger, nil); err != nil {
+		return nil, err
+	}
+
+	return &Trigger{
+		Trigger: trigger,
+	}, nil
+}
+
+func (c Collection) CreateTrigger(ctx context.Context, id string, triggerType api.TriggerType, operation api.TriggerOperation, body string) (*Trigger, error) {
+	span, ctx := c.startTriggerSpan(ctx, "cosmos.CreateTrigger", id)
+	defer span.Finish()
+
+	trigger := api.Trigger{
+		ID:               id,
+		Body:             body,
+		TriggerType:      triggerType,
+		TriggerOperation: operation,
+	}
+
+	if _, err := c.database.Client().post(ctx, createTriggerLink(c.database.ID, c.ID, ""), trigger, &trigger, nil); err != nil {
+		return nil, err
+	}
+
+	return &Trigger{
+		Trigger: trigger,
+	}, nil
+}
+
+func (c Collection) ReplaceTrigger(ctx context.Context, id string, triggerType api.TriggerType, operation api.TriggerOperation, body string) (*Trigger, error) {
+	span, ctx := c.startTriggerSpan(ctx, "cosmos.ReplaceTrigger", id)
+	defer span.Finish()
+
+	trigger := api.Trigger{
+		ID:               id,
+		Body:             body,
+		TriggerType:      triggerType,
+		TriggerOperation: operation,
+	}
+
+	if _, err := c.database.Client().put(ctx, createTriggerLink(c.database.ID, c.ID, id), trigger, &trigger, nil); err != nil {
+		return nil, err
+	}
+
+	return &Trigger{
+		Trigger: trigger,
+	}, nil
+}
+
+func (c Collection) DeleteTrigger(ctx context.Context, id string) error {
+	span, ctx := c.startTriggerSpan(ctx, "cosmos.DeleteTrigger", id)
+	defer span.Finish()
+
+	_, err := c.database.Client().delete(ctx, createTriggerLink(c.database.ID, c.ID, id), nil)
+	return err
+}
+
+func (c Collection) startTriggerSpan(ctx context.Context, operationName string, triggerID string) (opentracing.Span, context.Context) {
+	span, ctx := c.startCollectionSpan(ctx, operationName)
+	span.SetTag("cosmos.trigger", triggerID)
+
+	return span, ctx
+}
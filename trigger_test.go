@@ -0,0 +1,137 @@
+package cosmos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+	"github.com/zhevron/cosmos/cosmostest"
+	"github.com/zhevron/cosmos/query"
+)
+
+func TestTriggers(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	server.RegisterTrigger("defaultBalance", func(sc *cosmostest.ScriptContext, doc map[string]interface{}) error {
+		if _, ok := doc["balance"]; !ok || doc["balance"] == float64(0) {
+			doc["balance"] = 100
+		}
+		return nil
+	})
+	server.RegisterTrigger("audit", func(sc *cosmostest.ScriptContext, doc map[string]interface{}) error {
+		return sc.UpsertDocument(map[string]interface{}{"id": "audit-" + doc["id"].(string), "owner": doc["owner"]})
+	})
+	server.RegisterTrigger("reject", func(sc *cosmostest.ScriptContext, doc map[string]interface{}) error {
+		return errors.New("rejected")
+	})
+
+	if _, err := coll.CreateTrigger(ctx, "defaultBalance", api.TriggerTypePre, api.TriggerOperationCreate, "function() {}"); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	if _, err := coll.CreateTrigger(ctx, "audit", api.TriggerTypePost, api.TriggerOperationAll, "function() {}"); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	if _, err := coll.CreateTrigger(ctx, "reject", api.TriggerTypePost, api.TriggerOperationDelete, "function() {}"); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	triggers, err := coll.ListTriggers(ctx)
+	if err != nil {
+		t.Fatalf("failed to list triggers: %v", err)
+	}
+	if len(triggers) != 3 {
+		t.Errorf("expected 3 triggers, got %d", len(triggers))
+	}
+
+	doc := account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}
+	if err := coll.CreateDocument(ctx, "alice", doc, false, cosmos.WithPreTriggers("defaultBalance"), cosmos.WithPostTriggers("audit")); err != nil {
+		t.Fatalf("failed to create document: %v", err)
+	}
+
+	var created account
+	if err := coll.GetDocument(ctx, "alice", "1", &created); err != nil {
+		t.Fatalf("failed to get document: %v", err)
+	}
+	if created.Balance != 100 {
+		t.Errorf("expected pre-trigger to set balance to 100, got %d", created.Balance)
+	}
+
+	var audit account
+	if err := coll.GetDocument(ctx, "alice", "audit-1", &audit); err != nil {
+		t.Errorf("expected post-trigger to create audit document: %v", err)
+	}
+
+	if err := coll.ReplaceDocument(ctx, "alice", created, cosmos.WithPreTriggers("defaultBalance")); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected create-only trigger to be rejected on replace, got %v", err)
+	}
+
+	if err := coll.DeleteDocument(ctx, "alice", created, cosmos.WithPostTriggers("reject")); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected failing post-trigger to abort delete, got %v", err)
+	}
+	if err := coll.GetDocument(ctx, "alice", "1", &created); err != nil {
+		t.Errorf("expected document to survive aborted delete: %v", err)
+	}
+
+	if err := coll.DeleteTrigger(ctx, "reject"); err != nil {
+		t.Fatalf("failed to delete trigger: %v", err)
+	}
+	if _, err := coll.GetTrigger(ctx, "reject"); !cosmos.IsNotFound(err) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+}
+
+func TestUserDefinedFunctions(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	server.RegisterUserDefinedFunction("withInterest", func(args []interface{}) interface{} {
+		return args[0].(float64) * (1 + args[1].(float64))
+	})
+
+	if _, err := coll.CreateUserDefinedFunction(ctx, "withInterest", "function(balance, rate) { return balance * (1 + rate); }"); err != nil {
+		t.Fatalf("failed to create user-defined function: %v", err)
+	}
+
+	for i, owner := range []string{"alice", "bob"} {
+		if err := coll.CreateDocument(ctx, owner, account{Document: cosmos.Document{ID: "1"}, Owner: owner, Balance: (i + 1) * 100}, false); err != nil {
+			t.Fatalf("failed to create document: %v", err)
+		}
+	}
+
+	expr := query.UDF("withInterest", query.Field("c.balance"), 0.5)
+	if expr.String() != "udf.withInterest(c.balance, 0.5)" {
+		t.Errorf("unexpected expression %q", expr.String())
+	}
+
+	q := query.Select("*").Where(query.Greater(expr.String(), 200))
+	it, err := coll.QueryDocuments(ctx, nil, q.String())
+	if err != nil {
+		t.Fatalf("failed to query documents: %v", err)
+	}
+
+	var results []account
+	if err := it.All(&results); err != nil {
+		t.Fatalf("failed to read query results: %v", err)
+	}
+	if len(results) != 1 || results[0].Owner != "bob" {
+		t.Errorf("expected only bob to match, got %+v", results)
+	}
+
+	functions, err := coll.ListUserDefinedFunctions(ctx)
+	if err != nil {
+		t.Fatalf("failed to list user-defined functions: %v", err)
+	}
+	if len(functions) != 1 || functions[0].ID != "withInterest" {
+		t.Errorf("unexpected user-defined functions: %+v", functions)
+	}
+
+	if err := coll.DeleteUserDefinedFunction(ctx, "withInterest"); err != nil {
+		t.Fatalf("failed to delete user-defined function: %v", err)
+	}
+	if _, err := coll.QueryDocuments(ctx, nil, q.String()); !cosmos.IsBadRequest(err) {
+		t.Errorf("expected query with deleted function to fail, got %v", err)
+	}
+}
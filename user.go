@@ -0,0 +1,196 @@
+package cosmos
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type User struct {
+	api.User
+
+	database *Database
+}
+
+type Permission struct {
+	api.Permission
+}
+
+type PermissionOption func(*api.Permission, map[string]string)
+
+func WithResourcePartitionKey(partitionKey interface{}) PermissionOption {
+	return func(permission *api.Permission, headers map[string]string) {
+		permission.ResourcePartitionKey = []interface{}{partitionKey}
+	}
+}
+
+func WithTokenExpiry(expiry time.Duration) PermissionOption {
+	return func(permission *api.Permission, headers map[string]string) {
+		headers[api.HEADER_EXPIRY_SECONDS] = strconv.Itoa(int(expiry / time.Second))
+	}
+}
+
+func (d Database) ListUsers(ctx context.Context) ([]*User, error) {
+	span, ctx := d.startSpan(ctx, "cosmos.ListUsers")
+	defer span.Finish()
+
+	var res api.ListUsersResponse
+	if _, err := d.client.get(ctx, createUserLink(d.ID, ""), &res, nil); err != nil {
+		return nil, err
+	}
+
+	users := make([]*User, len(res.Users))
+	for i, u := range res.Users {
+		users[i] = &User{
+			User:     u,
+			database: &d,
+		}
+	}
+
+	return users, nil
+}
+
+func (d Database) GetUser(ctx context.Context, id string) (*User, error) {
+	span, ctx := d.startUserSpan(ctx, "cosmos.GetUser", id)
+	defer span.Finish()
+
+	var user api.User
+	if _, err := d.client.get(ctx, createUserLink(d.ID, id), &user, nil); err != nil {
+		return nil, err
+	}
+
+	return &User{
+		User:     user,
+		database: &d,
+	}, nil
+}
+
+func (d Database) CreateUser(ctx context.Context, id string) (*User, error) {
+	span, ctx := d.startUserSpan(ctx, "cosmos.CreateUser", id)
+	defer span.Finish()
+
+	user := api.User{
+		ID: id,
+	}
+
+	if _, err := d.client.post(ctx, createUserLink(d.ID, ""), user, &user, nil); err != nil {
+		return nil, err
+	}
+
+	return &User{
+		User:     user,
+		database: &d,
+	}, nil
+}
+
+func (d Database) DeleteUser(ctx context.Context, id string) error {
+	span, ctx := d.startUserSpan(ctx, "cosmos.DeleteUser", id)
+	defer span.Finish()
+
+	_, err := d.client.delete(ctx, createUserLink(d.ID, id), nil)
+	return err
+}
+
+func (u User) Database() *Database {
+	return u.database
+}
+
+func (u User) ListPermissions(ctx context.Context) ([]*Permission, error) {
+	span, ctx := u.database.startUserSpan(ctx, "cosmos.ListPermissions", u.ID)
+	defer span.Finish()
+
+	var res api.ListPermissionsResponse
+	if _, err := u.database.client.get(ctx, createPermissionLink(u.database.ID, u.ID, ""), &res, nil); err != nil {
+		return nil, err
+	}
+
+	permissions := make([]*Permission, len(res.Permissions))
+	for i, p := range res.Permissions {
+		permissions[i] = &Permission{
+			Permission: p,
+		}
+	}
+
+	return permissions, nil
+}
+
+func (u User) GetPermission(ctx context.Context, id string, opts ...PermissionOption) (*Permission, error) {
+	span, ctx := u.database.startUserSpan(ctx, "cosmos.GetPermission", u.ID)
+	defer span.Finish()
+
+	headers := make(map[string]string)
+	for _, opt := range opts {
+		opt(&api.Permission{}, headers)
+	}
+
+	var permission api.Permission
+	if _, err := u.database.client.get(ctx, createPermissionLink(u.database.ID, u.ID, id), &permission, headers); err != nil {
+		return nil, err
+	}
+
+	return &Permission{
+		Permission: permission,
+	}, nil
+}
+
+func (u User) CreatePermission(ctx context.Context, id string, mode api.PermissionMode, resourceLink string, opts ...PermissionOption) (*Permission, error) {
+	span, ctx := u.database.startUserSpan(ctx, "cosmos.CreatePermission", u.ID)
+	defer span.Finish()
+
+	permission, headers := newPermission(id, mode, resourceLink, opts)
+	if _, err := u.database.client.post(ctx, createPermissionLink(u.database.ID, u.ID, ""), permission, &permission, headers); err != nil {
+		return nil, err
+	}
+
+	return &Permission{
+		Permission: permission,
+	}, nil
+}
+
+func (u User) ReplacePermission(ctx context.Context, id string, mode api.PermissionMode, resourceLink string, opts ...PermissionOption) (*Permission, error) {
+	span, ctx := u.database.startUserSpan(ctx, "cosmos.ReplacePermission", u.ID)
+	defer span.Finish()
+
+	permission, headers := newPermission(id, mode, resourceLink, opts)
+	if _, err := u.database.client.put(ctx, createPermissionLink(u.database.ID, u.ID, id), permission, &permission, headers); err != nil {
+		return nil, err
+	}
+
+	return &Permission{
+		Permission: permission,
+	}, nil
+}
+
+func (u User) DeletePermission(ctx context.Context, id string) error {
+	span, ctx := u.database.startUserSpan(ctx, "cosmos.DeletePermission", u.ID)
+	defer span.Finish()
+
+	_, err := u.database.client.delete(ctx, createPermissionLink(u.database.ID, u.ID, id), nil)
+	return err
+}
+
+func newPermission(id string, mode api.PermissionMode, resourceLink string, opts []PermissionOption) (api.Permission, map[string]string) {
+	headers := make(map[string]string)
+	permission := api.Permission{
+		ID:             id,
+		PermissionMode: mode,
+		Resource:       resourceLink,
+	}
+
+	for _, opt := range opts {
+		opt(&permission, headers)
+	}
+
+	return permission, headers
+}
+
+func (d Database) startUserSpan(ctx context.Context, operationName string, userID string) (opentracing.Span, context.Context) {
+	span, ctx := d.startSpan(ctx, operationName)
+	span.SetTag("cosmos.user", userID)
+
+	return span, ctx
+}
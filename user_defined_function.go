@@ -0,0 +1,97 @@
+package cosmos
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/zhevron/cosmos/api"
+)
+
+type UserDefinedFunction struct {
+	api.UserDefinedFunction
+}
+
+func (c Collection) ListUserDefinedFunctions(ctx context.Context) ([]*UserDefinedFunction, error) {
+	span, ctx := c.startCollectionSpan(ctx, "cosmos.ListUserDefinedFunctions")
+	defer span.Finish()
+
+	var res api.ListUserDefinedFunctionsResponse
+	if _, err := c.database.Client().get(ctx, createUserDefinedFunctionLink(c.database.ID, c.ID, ""), &res, nil); err != nil {
+		return nil, err
+	}
+
+	functions := make([]*UserDefinedFunction, len(res.UserDefinedFunctions))
+	for i, f := range res.UserDefinedFunctions {
+		functions[i] = &UserDefinedFunction{
+			UserDefinedFunction: f,
+		}
+	}
+
+	return functions, nil
+}
+
+func (c Collection) GetUserDefinedFunction(ctx context.Context, id string) (*UserDefinedFunction, error) {
+	span, ctx := c.startUserDefinedFunctionSpan(ctx, "cosmos.GetUserDefinedFunction", id)
+	defer span.Finish()
+
+	var function api.UserDefinedFunction
+	if _, err := c.database.Client().get(ctx, createUserDefinedFunctionLink(c.database.ID, c.ID, id), &function, nil); err != nil {
+		return nil, err
+	}
+
+	return &UserDefinedFunction{
+		UserDefinedFunction: function,
+	}, nil
+}
+
+func (c Collection) CreateUserDefinedFunction(ctx context.Context, id string, body string) (*UserDefinedFunction, error) {
+	span, ctx := c.startUserDefinedFunctionSpan(ctx, "cosmos.CreateUserDefinedFunction", id)
+	defer span.Finish()
+
+	function := api.UserDefinedFunction{
+		ID:   id,
+		Body: body,
+	}
+
+	if _, err := c.database.Client().post(ctx, createUserDefinedFunctionLink(c.database.ID, c.ID, ""), function, &function, nil); err != nil {
+		return nil, err
+	}
+
+	return &UserDefinedFunction{
+		UserDefinedFunction: function,
+	}, nil
+}
+
+func (c Collection) ReplaceUserDefinedFunction(ctx context.Context, id string, body string) (*UserDefinedFunction, error) {
+	span, ctx := c.startUserDefinedFunctionSpan(ctx, "cosmos.ReplaceUserDefinedFunction", id)
+	defer span.Finish()
+
+	function := api.UserDefinedFunction{
+		ID:   id,
+		Body: body,
+	}
+
+	if _, err := c.database.Client().put(ctx, createUserDefinedFunctionLink(c.database.ID, c.ID, id), function, &function, nil); err != nil {
+		return nil, err
+	}
+
+	return &UserDefinedFunction{
+		UserDefinedFunction: function,
+	}, nil
+}
+
+func (c Collection) DeleteUserDefinedFunction(ctx context.Context, id string) error {
+	span, ctx := c.startUserDefinedFunctionSpan(ctx, "cosmos.DeleteUserDefinedFunction", id)
+	defer span.Finish()
+
+	_, err := c.database.Client().delete(ctx, createUserDefinedFunctionLink(c.database.ID, c.ID, id), nil)
+	return err
+}
+
+func (c Collection) startUserDefinedFunctionSpan(ctx context.Context, operationName string, functionID string) (opentracing.Span, context.Context) {
+	span, ctx := c.startCollectionSpan(ctx, operationName)
+	span.SetTag("cosmos.user_defined_function", functionID)
+
+	return span, ctx
+}
@@ -0,0 +1,170 @@
+package cosmos_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zhevron/cosmos"
+	"github.com/zhevron/cosmos/api"
+)
+
+func TestUsersAndPermissions(t *testing.T) {
+	_, coll := newTestCollection(t)
+	db := coll.Database()
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, "mobile")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	users, err := db.ListUsers(ctx)
+	if err != nil {
+		t.Fatalf("failed to list users: %v", err)
+	}
+	if len(users) != 1 || users[0].ID != "mobile" {
+		t.Fatalf("expected user mobile, got %v", users)
+	}
+
+	permission, err := user.CreatePermission(ctx, "accounts", api.PermissionModeAll, "dbs/bank/colls/accounts", cosmos.WithResourcePartitionKey("alice"))
+	if err != nil {
+		t.Fatalf("failed to create permission: %v", err)
+	}
+	if !strings.HasPrefix(permission.Token, "type=resource&") {
+		t.Errorf("expected a resource token, got %q", permission.Token)
+	}
+	if len(permission.ResourcePartitionKey) != 1 || permission.ResourcePartitionKey[0] != "alice" {
+		t.Errorf("expected resource partition key alice, got %v", permission.ResourcePartitionKey)
+	}
+
+	replaced, err := user.ReplacePermission(ctx, "accounts", api.PermissionModeRead, "dbs/bank/colls/accounts")
+	if err != nil {
+		t.Fatalf("failed to replace permission: %v", err)
+	}
+	if replaced.PermissionMode != api.PermissionModeRead || replaced.Token == permission.Token {
+		t.Errorf("expected a new read-only token, got %+v", replaced.Permission)
+	}
+
+	fetched, err := user.GetPermission(ctx, "accounts")
+	if err != nil {
+		t.Fatalf("failed to get permission: %v", err)
+	}
+	if fetched.Token != replaced.Token {
+		t.Errorf("expected token %q, got %q", replaced.Token, fetched.Token)
+	}
+
+	permissions, err := user.ListPermissions(ctx)
+	if err != nil {
+		t.Fatalf("failed to list permissions: %v", err)
+	}
+	if len(permissions) != 1 {
+		t.Fatalf("expected 1 permission, got %d", len(permissions))
+	}
+
+	if err := user.DeletePermission(ctx, "accounts"); err != nil {
+		t.Fatalf("failed to delete permission: %v", err)
+	}
+	if err := db.DeleteUser(ctx, "mobile"); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if _, err := db.GetUser(ctx, "mobile"); !cosmos.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestResourceTokens(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	for _, owner := range []string{"alice", "bob"} {
+		if err := coll.CreateDocument(ctx, owner, account{Document: cosmos.Document{ID: owner}, Owner: owner}, false); err != nil {
+			t.Fatalf("failed to create document: %v", err)
+		}
+	}
+
+	user, err := coll.Database().CreateUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	permission, err := user.CreatePermission(ctx, "accounts", api.PermissionModeAll, "dbs/bank/colls/accounts", cosmos.WithResourcePartitionKey("alice"))
+	if err != nil {
+		t.Fatalf("failed to create permission: %v", err)
+	}
+
+	endpoint, _ := url.Parse(server.URL)
+	client, err := cosmos.Dial(
+		cosmos.WithEndpoint(endpoint),
+		cosmos.WithResourceTokens(map[string]string{permission.Resource: permission.Token}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	scoped, err := client.Database("bank").GetCollection(ctx, "accounts")
+	if err != nil {
+		t.Fatalf("failed to get collection with resource token: %v", err)
+	}
+
+	header, _ := url.QueryUnescape(server.LastRequestHeader(api.HEADER_AUTHORIZATION))
+	if header != permission.Token {
+		t.Errorf("expected the resource token to be sent, got %q", header)
+	}
+
+	var acc account
+	if err := scoped.GetDocument(ctx, "alice", "alice", &acc); err != nil {
+		t.Fatalf("failed to get document with resource token: %v", err)
+	}
+	if err := scoped.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "savings"}, Owner: "alice"}, false); err != nil {
+		t.Fatalf("failed to create document with resource token: %v", err)
+	}
+
+	if err := scoped.GetDocument(ctx, "bob", "bob", &acc); !cosmos.IsForbidden(err) {
+		t.Errorf("expected forbidden outside the partition key, got %v", err)
+	}
+	if _, err := client.ListDatabases(ctx); !cosmos.InUnauthorized(err) {
+		t.Errorf("expected unauthorized without a matching token, got %v", err)
+	}
+
+	if err := user.DeletePermission(ctx, "accounts"); err != nil {
+		t.Fatalf("failed to delete permission: %v", err)
+	}
+	if err := scoped.GetDocument(ctx, "alice", "alice", &acc); !cosmos.InUnauthorized(err) {
+		t.Errorf("expected a revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestReadOnlyResourceToken(t *testing.T) {
+	server, coll := newTestCollection(t)
+	ctx := context.Background()
+
+	user, err := coll.Database().CreateUser(ctx, "reader")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	permission, err := user.CreatePermission(ctx, "accounts", api.PermissionModeRead, "dbs/bank/colls/accounts")
+	if err != nil {
+		t.Fatalf("failed to create permission: %v", err)
+	}
+
+	endpoint, _ := url.Parse(server.URL)
+	client, err := cosmos.Dial(
+		cosmos.WithEndpoint(endpoint),
+		cosmos.WithResourceTokens(map[string]string{permission.Resource: permission.Token}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	scoped, err := client.Database("bank").GetCollection(ctx, "accounts")
+	if err != nil {
+		t.Fatalf("failed to get collection with resource token: %v", err)
+	}
+
+	if err := scoped.CreateDocument(ctx, "alice", account{Document: cosmos.Document{ID: "1"}, Owner: "alice"}, false); !cosmos.IsForbidden(err) {
+		t.Errorf("expected forbidden for a write with a read-only token, got %v", err)
+	}
+}